@@ -0,0 +1,58 @@
+/*
+ *-----------------------------------------------------------------
+ * IMDb Chart Fetcher - ASCII JSON
+ *-----------------------------------------------------------------
+ * Description: Pure ASCII JSON for the consumers that cannot take
+ *              UTF-8 (e.g. the legacy tooling expecting Latin-1),
+ *              asked for by -ascii. The characters beyond ASCII, e.g.
+ *              of the Tamil & Telugu titles, are escaped as \uXXXX,
+ *              those beyond the Basic Multilingual Plane as a UTF-16
+ *              surrogate pair, which any JSON parser decodes back to
+ *              the same text. Such characters only occur within the
+ *              strings of the JSON, so the escaping is done on the
+ *              marshalled JSON as is.
+ *-----------------------------------------------------------------
+ */
+package main
+
+import (
+    "fmt"
+    "bytes"
+    "unicode/utf8"
+    "unicode/utf16"
+)
+
+// asciiJSON provides the JSON with the characters beyond ASCII escaped, as is if it
+// has none.
+func asciiJSON (data []byte) []byte {
+
+    if !hasNonASCII (data) {
+        return data
+    }
+
+    var buf bytes.Buffer
+    for len (data) > 0 {
+        r, size := utf8.DecodeRune (data)
+        switch {
+        case r < utf8.RuneSelf:
+            buf.WriteByte (data[0])
+        case r >= 0x10000:
+            r1, r2 := utf16.EncodeRune (r)
+            fmt.Fprintf (&buf, `\u%04x\u%04x`, r1, r2)
+        default:
+            fmt.Fprintf (&buf, `\u%04x`, r)
+        }
+        data = data[size : ]
+    }
+    return buf.Bytes()
+}
+
+// hasNonASCII tells whether the data has any byte beyond ASCII.
+func hasNonASCII (data []byte) bool {
+    for _, b := range data {
+        if b >= utf8.RuneSelf {
+            return true
+        }
+    }
+    return false
+}
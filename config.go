@@ -0,0 +1,101 @@
+/*
+ *-----------------------------------------------------------------
+ * IMDb Chart Fetcher - Run Configuration
+ *-----------------------------------------------------------------
+ * Description: Records how an output was produced, i.e. the URL &
+ *              count along with the effective value of every option
+ *              (given or default), the version of the program & the
+ *              start time of the run. Written to the file given by
+ *              -record-config & added to the envelope as "config",
+ *              so that an old output can be traced & reproduced.
+ *              The cookies given via -cookie are not recorded, nor
+ *              the credentials (user:password@) of the URL options.
+ *              The version is set at build time:
+ *               go build -ldflags "-X main.version=1.2.0" -o imdb_chart_fetcher .
+ *-----------------------------------------------------------------
+ */
+package main
+
+import (
+    "flag"
+    "time"
+    "net/url"
+    "io/ioutil"
+    "encoding/json"
+)
+
+// value recorded in place of the options that are secret
+const (
+    config_Redacted = `redacted`
+)
+
+// options having a URL, which may carry credentials as its user info
+var configURLOptions = []string {"proxy", "translate-url"}
+
+// version of the program, set at build time
+var version = "dev"
+
+// configuration of the run, when it is to be recorded
+var runConfig *RunConfig
+
+// Structure to maintain the configuration of a run
+// facilitates easy conversion from structure to json by using the meta-fields
+type RunConfig struct {
+    URL       string            `json:"url,omitempty"`
+    Count     int               `json:"count"`
+    Options   map[string]string `json:"options"`
+    Version   string            `json:"version"`
+    StartedAt string            `json:"started_at"`
+}
+
+// newRunConfig provides the configuration of the current run, having the effective
+// value of every command-line option.
+func newRunConfig (chartUrl string, itemCount int) *RunConfig {
+
+    options := map[string]string {}
+    flag.VisitAll(func (f *flag.Flag) {
+        options[f.Name] = f.Value.String()
+    })
+
+    // the cookies are the credentials of the browser, not to be kept with the output
+    if options["cookie"] != "" {
+        options["cookie"] = config_Redacted
+    }
+    for _, name := range configURLOptions {
+        if options[name] != "" {
+            options[name] = redactURL (options[name])
+        }
+    }
+
+    return &RunConfig{
+        URL:       chartUrl,
+        Count:     itemCount,
+        Options:   options,
+        Version:   version,
+        StartedAt: time.Now().Format(time.RFC3339),
+    }
+}
+
+// redactURL provides the URL with its user info, if any, redacted, or the URL redacted
+// altogether if it cannot be parsed.
+func redactURL (rawUrl string) string {
+
+    u, err := url.Parse (rawUrl)
+    if err != nil {
+        return config_Redacted
+    }
+    if u.User != nil {
+        u.User = url.User (config_Redacted)
+    }
+    return u.String()
+}
+
+// writeRunConfig writes the configuration as JSON to the file.
+func writeRunConfig (config *RunConfig, path string) error {
+
+    data, err := json.MarshalIndent (config, "", "  ")
+    if err != nil {
+        return err
+    }
+    return ioutil.WriteFile (path, data, 0644)
+}
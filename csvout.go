@@ -0,0 +1,52 @@
+/*
+ *-----------------------------------------------------------------
+ * IMDb Chart Fetcher - CSV
+ *-----------------------------------------------------------------
+ * Description: Serializes the fetched movies as CSV for loading into
+ *              a spreadsheet, e.g.
+ *                imdb_chart_fetcher -format=csv <url> 10 > movies.csv
+ *              A header row is followed by one row per movie:
+ *               title,movie_release_year,imdb_rating,duration,genre,summary
+ *              The fields having commas, quotes or newlines (e.g. the
+ *              summary) are quoted as per RFC 4180 by encoding/csv.
+ *              The rows are of the same movies as the JSON output.
+ *-----------------------------------------------------------------
+ */
+package main
+
+import (
+    "strconv"
+    "strings"
+    "encoding/csv"
+
+    "github.com/sadhroh/Imdb-crawler/imdb"
+)
+
+// header row, in the order of the fields in the rows
+var csv_Header = []string {"title", "movie_release_year", "imdb_rating", "duration", "genre", "summary"}
+
+// csvRows provides the header row & a row per movie as CSV.
+func csvRows (imdbChartTable []imdb.ImdbChartData) (string, error) {
+
+    var sb strings.Builder
+
+    w := csv.NewWriter (&sb)
+    w.Write (csv_Header)
+    for _, mov := range imdbChartTable {
+        w.Write ([]string {
+            mov.Title,
+            strconv.FormatUint(mov.ReleaseYear, 10),
+            strconv.FormatFloat(mov.Rating, 'f', -1, 64),
+            mov.Duration,
+            mov.Genre,
+            mov.Summary,
+        })
+    }
+    w.Flush()
+    if err := w.Error(); err != nil {
+        return "", err
+    }
+
+    // the output is ended with a newline anyway
+    return strings.TrimSuffix(sb.String(), "\n"), nil
+}
@@ -0,0 +1,52 @@
+/*
+ *-----------------------------------------------------------------
+ * IMDb Chart Fetcher - Error Log
+ *-----------------------------------------------------------------
+ * Description: Sidecar of the movies that could not be fetched in
+ *              full, asked for by -error-log, for a targeted re-run
+ *              of those only, e.g. via -ids-from. Each of them is a
+ *              line of JSON with its rank, IMDb title ID, title (if
+ *              known) & detail URL along with the errors, i.e. the
+ *              fields that failed, each with the message of its
+ *              failure, e.g.
+ *                {"rank":7,"imdb_id":"tt0093603","title":"Nayakan",
+ *                 "detail_url":"...","errors":[{"field":"details",
+ *                 "message":"Could not fetch more info. ..."}]}
+ *              The movies are output as usual, the complete ones &
+ *              the failed ones alike. The file is empty if none of
+ *              them failed.
+ *-----------------------------------------------------------------
+ */
+package main
+
+import (
+    "io"
+
+    "github.com/sadhroh/Imdb-crawler/imdb"
+)
+
+// Structure to maintain a movie that could not be fetched in full, as a line of the
+// error log.
+type FailedMovie struct {
+    Rank      int               `json:"rank"`
+    IMDbID    string            `json:"imdb_id"`
+    Title     string            `json:"title"`
+    DetailURL string            `json:"detail_url"`
+    Errors    []imdb.FieldError `json:"errors"`
+}
+
+// writeErrorLog writes each of the movies having errors to w as a line of JSON.
+func writeErrorLog (imdbChartTable []imdb.ImdbChartData, w io.Writer) error {
+
+    lw := newLineWriter (w)
+    for _, mov := range imdbChartTable {
+        if len (mov.FieldErrors) == 0 {
+            continue
+        }
+        failed := FailedMovie{mov.Rank, mov.IMDbID, mov.Title, mov.DetailURL, mov.FieldErrors}
+        if err := lw.WriteJSON (failed); err != nil {
+            return err
+        }
+    }
+    return nil
+}
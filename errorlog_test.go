@@ -0,0 +1,42 @@
+/*
+ *-----------------------------------------------------------------
+ * IMDb Chart Fetcher - Tests of the error log
+ *-----------------------------------------------------------------
+ */
+package main
+
+import (
+    "bytes"
+    "testing"
+
+    "github.com/sadhroh/Imdb-crawler/imdb"
+)
+
+func TestWriteErrorLog (t *testing.T) {
+
+    movies := []imdb.ImdbChartData {
+        {Rank: 1, TitleData: imdb.TitleData{IMDbID: "tt0000001", Title: "Nayakan"}},
+        {
+            Rank:        2,
+            TitleData:   imdb.TitleData{IMDbID: "tt0000002", Title: "Anbe Sivam", DetailURL: "https://www.imdb.com/title/tt0000002/"},
+            Errors:      []string {"details"},
+            FieldErrors: []imdb.FieldError {{Field: "details", Message: "Could not fetch more info. 503"}},
+        },
+        {
+            Rank:        3,
+            TitleData:   imdb.TitleData{IMDbID: "tt0000003"},
+            Errors:      []string {"imdb_rating", "votes"},
+            FieldErrors: []imdb.FieldError {{Field: "imdb_rating", Message: "Could not obtain rating"}, {Field: "votes", Message: "Could not obtain number of votes"}},
+        },
+    }
+    want := `{"rank":2,"imdb_id":"tt0000002","title":"Anbe Sivam","detail_url":"https://www.imdb.com/title/tt0000002/","errors":[{"field":"details","message":"Could not fetch more info. 503"}]}` + "\n" +
+            `{"rank":3,"imdb_id":"tt0000003","title":"","detail_url":"","errors":[{"field":"imdb_rating","message":"Could not obtain rating"},{"field":"votes","message":"Could not obtain number of votes"}]}` + "\n"
+
+    var buf bytes.Buffer
+    if err := writeErrorLog (movies, &buf); err != nil {
+        t.Fatal (err)
+    }
+    if buf.String() != want {
+        t.Errorf ("error log\n%s\nwant\n%s", buf.String(), want)
+    }
+}
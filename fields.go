@@ -0,0 +1,134 @@
+/*
+ *-----------------------------------------------------------------
+ * IMDb Chart Fetcher - Fields
+ *-----------------------------------------------------------------
+ * Description: Narrows the JSON output down to the fields asked
+ *              for by -fields, e.g.
+ *                imdb_chart_fetcher -fields=title,imdb_rating <url> 10
+ *              gives [{"title":"...","imdb_rating":8.6}, ...] with
+ *              the fields in the order asked for. The fields are
+ *              named as in the JSON output, with rating & year as
+ *              the short names of imdb_rating & movie_release_year.
+ *              Unless one of the fields is of the detail page (e.g.
+ *              summary), the detail pages are not crawled at all,
+ *              i.e. one request less per movie.
+ *-----------------------------------------------------------------
+ */
+package main
+
+import (
+    "bytes"
+    "strings"
+    "encoding/json"
+
+    "github.com/sadhroh/Imdb-crawler/imdb"
+)
+
+// fields of the movie, telling whether each is obtained from its detail page
+var movieFields = map[string]bool {
+    "rank":               false,
+    "imdb_id":            false,
+    "title":              false,
+    "movie_release_year": false,
+    "end_year":           false,
+    "detail_url":         false,
+    "imdb_rating":        false,
+    "votes":              false,
+    "errors":             false,
+    "weekend_gross":      false,
+    "total_gross":        false,
+    "weeks_released":     false,
+    "poster_url":         false,
+    "summary":            true,
+    "storyline":          true,
+    "translated_summary": true,
+    "duration":           true,
+    "duration_minutes":   true,
+    "genre":              true,
+    "collapsed_genres":   true,
+    "title_type":         true,
+    "directors":          true,
+    "stars":              true,
+    "cast":               true,
+    "episodes":           true,
+}
+
+// fields asked for by -fields, all of them if none
+var outputFields []string
+
+// short names of the fields
+var fieldAliases = map[string]string {
+    "rating": "imdb_rating",
+    "year":   "movie_release_year",
+}
+
+// parseFields provides the fields of the comma separated list by their JSON names,
+// along with whether any of them needs the detail page. The name of the first field
+// not known is given as well, if any.
+func parseFields (list string) ([]string, bool, string) {
+
+    var fields []string
+    details := false
+
+    for _, name := range strings.Split(list, ",") {
+        name = strings.TrimSpace(name)
+        if alias, ok := fieldAliases[name]; ok {
+            name = alias
+        }
+        detail, ok := movieFields[name]
+        if !ok {
+            return nil, false, name
+        }
+        fields = append (fields, name)
+        details = details || detail
+    }
+    return fields, details, ""
+}
+
+// Structure to maintain a movie to be output with the given fields only, in their
+// order. The fields the movie does not have (e.g. episodes of a movie) are left out.
+type pickedMovie struct {
+    movie  imdb.ImdbChartData
+    fields []string
+}
+
+func (m pickedMovie) MarshalJSON () ([]byte, error) {
+
+    full, err := json.Marshal (m.movie)
+    if err != nil {
+        return nil, err
+    }
+    var values map[string]json.RawMessage
+    if err := json.Unmarshal (full, &values); err != nil {
+        return nil, err
+    }
+
+    var buf bytes.Buffer
+    buf.WriteByte ('{')
+    for _, field := range m.fields {
+        value, ok := values[field]
+        if !ok {
+            continue
+        }
+        if buf.Len() > 1 {
+            buf.WriteByte (',')
+        }
+        key, _ := json.Marshal (field)
+        buf.Write (key)
+        buf.WriteByte (':')
+        buf.Write (value)
+    }
+    buf.WriteByte ('}')
+    return buf.Bytes(), nil
+}
+
+// pickFields projects the movies onto the given fields.
+func pickFields (imdbChartTable []imdb.ImdbChartData, fields []string) []pickedMovie {
+
+    picked := make([]pickedMovie, len (imdbChartTable))
+
+    for i, mov := range imdbChartTable {
+        picked[i] = pickedMovie{mov, fields}
+    }
+    return picked
+}
@@ -0,0 +1,89 @@
+/*
+ *-----------------------------------------------------------------
+ * IMDb Chart Fetcher - Adaptive Rate
+ *-----------------------------------------------------------------
+ * Description: Limits the number of concurrent requests to IMDb,
+ *              adapting the limit to the responses (AIMD), so that
+ *              the crawl is as fast as IMDb allows without tuning.
+ *               - the limit starts at 1 & grows by 1 for every limit
+ *                 number of responses that are OK & fast enough
+ *               - the limit is halved on a 429 (Too Many Requests),
+ *                 a 503 (Service Unavailable) or a response slower
+ *                 than the latency given by -adaptive-latency
+ *              The limit stays between 1 & the maximum given by
+ *              -adaptive. It wraps any Fetcher.
+ *-----------------------------------------------------------------
+ */
+package imdb
+
+import (
+    "sync"
+    "time"
+    "math"
+    "context"
+    "errors"
+    "net/http"
+)
+
+// adaptiveFetcher is the Fetcher which limits the concurrent fetches of the wrapped
+// Fetcher adaptively.
+type adaptiveFetcher struct {
+    Fetcher
+    max      float64
+    latency  time.Duration
+
+    mu       sync.Mutex
+    cond     *sync.Cond
+    limit    float64
+    inFlight int
+}
+
+// newAdaptiveFetcher wraps the Fetcher to allow up to max concurrent fetches, slowing
+// down when a response takes longer than the given latency.
+func newAdaptiveFetcher (f Fetcher, max int, latency time.Duration) *adaptiveFetcher {
+
+    a := &adaptiveFetcher{Fetcher: f, max: float64(max), latency: latency, limit: 1}
+    a.cond = sync.NewCond (&a.mu)
+    return a
+}
+
+// Get obtains the page via the wrapped Fetcher once the limit allows & adapts the
+// limit as per the outcome.
+func (a *adaptiveFetcher) Get (ctx context.Context, url string) (string, error) {
+
+    a.mu.Lock()
+    for float64(a.inFlight) >= math.Floor(a.limit) {
+        a.cond.Wait()
+    }
+    a.inFlight++
+    a.mu.Unlock()
+
+    start := time.Now()
+    body, err := a.Fetcher.Get (ctx, url)
+    took := time.Since (start)
+
+    a.mu.Lock()
+    a.inFlight--
+    switch {
+    case isThrottled (err) || took > a.latency:
+        // multiplicative decrease
+        a.limit = math.Max (1, a.limit / 2)
+    case err == nil:
+        // additive increase, by 1 for every limit number of responses
+        a.limit = math.Min (a.max, a.limit + 1 / a.limit)
+    }
+    a.cond.Broadcast()
+    a.mu.Unlock()
+
+    return body, err
+}
+
+// isThrottled tells whether the error is IMDb asking to slow down.
+func isThrottled (err error) bool {
+
+    var se statusError
+    if !errors.As (err, &se) {
+        return false
+    }
+    return se.code == http.StatusTooManyRequests || se.code == http.StatusServiceUnavailable
+}
@@ -0,0 +1,81 @@
+/*
+ *-----------------------------------------------------------------
+ * IMDb Chart Fetcher - Baseline
+ *-----------------------------------------------------------------
+ * Description: Comparison of the fetched list of movies against an
+ *              earlier output of the program (the baseline), to get
+ *              only the movies that newly entered the chart.
+ *              The movies are matched by their IMDb title ID, so the
+ *              changes in rating or rank are ignored.
+ *              The baseline can be any earlier output i.e. the bare
+ *              array or the envelope, full or lite.
+ *-----------------------------------------------------------------
+ */
+package imdb
+
+import (
+    "regexp"
+    "io/ioutil"
+    "encoding/json"
+)
+
+// IMDb title ID as present in the link of the movie e.g. /title/tt0093603/, whatever
+// the query of the link e.g. ?ref_=tt_ov_inf
+var titleIDRegexp = regexp.MustCompile (`\btt\d+\b`)
+
+// Structure to maintain the fields of a baseline movie needed for matching, the
+// URL is used for the lite output of the earlier versions which did not have the ID.
+type baselineMovie struct {
+    IMDbID string `json:"imdb_id"`
+    URL    string `json:"url"`
+}
+
+// titleID provides the IMDb title ID from the link of the movie, or empty if the
+// link does not have it.
+func titleID (lnk string) string {
+    return titleIDRegexp.FindString(lnk)
+}
+
+// LoadBaseline provides the set of the title IDs of the movies in the baseline
+// file.
+func LoadBaseline (path string) (map[string]bool, error) {
+
+    data, err := ioutil.ReadFile (path)
+    if err != nil {
+        return nil, err
+    }
+
+    // either the bare array or the envelope
+    var movies []baselineMovie
+    if err := json.Unmarshal (data, &movies); err != nil {
+        var envelope struct {
+            Movies []baselineMovie `json:"movies"`
+        }
+        if err := json.Unmarshal (data, &envelope); err != nil {
+            return nil, err
+        }
+        movies = envelope.Movies
+    }
+
+    ids := map[string]bool {}
+    for _, mov := range movies {
+        if mov.IMDbID == "" {
+            mov.IMDbID = titleID (mov.URL)
+        }
+        ids[mov.IMDbID] = true
+    }
+    return ids, nil
+}
+
+// filterNew provides only the movies which are not present in the baseline.
+func filterNew (imdbChartTable []ImdbChartData, baseline map[string]bool) []ImdbChartData {
+
+    filtered := []ImdbChartData {}
+
+    for _, mov := range imdbChartTable {
+        if !baseline[mov.IMDbID] {
+            filtered = append (filtered, mov)
+        }
+    }
+    return filtered
+}
@@ -0,0 +1,130 @@
+/*
+ *-----------------------------------------------------------------
+ * IMDb Chart Fetcher - Box Office
+ *-----------------------------------------------------------------
+ * Description: Layout of the weekend box-office chart i.e.
+ *              https://www.imdb.com/chart/boxoffice
+ *              The chart is a table like the other charts, but each
+ *              row has the weekend gross, the total gross & the weeks
+ *              in release instead of the rating & the release year:
+ *                <td class="titleColumn"><a href="/title/tt...">..</a></td>
+ *                <td class="ratingColumn">$26.5M</td>
+ *                <td class="ratingColumn"><span class="secondaryInfo">$43.1M</span></td>
+ *                <td class="weeksColumn">2</td>
+ *              The grosses are provided in US dollars. The summary,
+ *              duration & genre are crawled from the detail page,
+ *              same as for the other charts.
+ *-----------------------------------------------------------------
+ */
+package imdb
+
+import (
+    "sync"
+    "regexp"
+    "context"
+    "strings"
+    "strconv"
+)
+
+// HTML element classes of the box-office chart used as selectors
+const (
+    bo_grossClass = `ratingColumn`
+    bo_weeksClass = `weeksColumn`
+)
+
+// Structure to maintain the box-office figures of a movie in the box-office chart
+// facilitates easy conversion from structure to json by using the meta-fields
+type BoxOffice struct {
+    WeekendGross  uint64 `json:"weekend_gross"`
+    TotalGross    uint64 `json:"total_gross"`
+    WeeksReleased int    `json:"weeks_released"`
+}
+
+// layout of the box-office chart, where each movie is a row of the table
+var boxOfficeLayout = listLayout{chartTable, chartRows, getBoxOfficeTitleData, getBoxOfficeRating}
+
+// selectors of the row of the box-office chart
+var (
+    boTitleRegexp = regexp.MustCompile (`(?s)<td class="` + td_titleClass + `">\s*<a href="([^"?]*)[^"]*"[^>]*>(.*?)</a>`)
+    boGrossRegexp = regexp.MustCompile (`(?s)<td class="` + bo_grossClass + `">(.*?)</td>`)
+    boWeeksRegexp = regexp.MustCompile (`<td class="` + bo_weeksClass + `">\s*(\d+)\s*</td>`)
+    grossRegexp   = regexp.MustCompile (`\$([\d.,]+)\s*([KMB]?)`)
+)
+
+// multipliers of the abbreviated grosses e.g. $26.5M
+var grossUnits = map[string]float64 {
+    "":  1,
+    "K": 1e3,
+    "M": 1e6,
+    "B": 1e9,
+}
+
+// getBoxOfficeTitleData is triggered as a goroutine and it fetches & parses the data
+// from the row of the box-office chart. Like getTitleData, the title & the box-office
+// figures are parsed from the row & then the summary, genre & duration are fetched
+// from the detail page.
+func getBoxOfficeTitleData (ctx context.Context, movieRec string, t *TitleData, errs *[]FieldError, wg *sync.WaitGroup) {
+
+    defer wg.Done()
+
+    // link to more info, without the query string & the title
+    lnkMatch := boTitleRegexp.FindStringSubmatch(movieRec)
+    if lnkMatch == nil {
+        failField (errs, field_Title, "Could not find the title in the box-office chart")
+        return
+    }
+    moreInfoURL := imdb_url_Main + lnkMatch[1]
+    t.DetailURL = moreInfoURL
+    t.IMDbID = titleID (moreInfoURL)
+
+    title := cleanTitle (ctx, stripTags (lnkMatch[2]))
+    t.Title = title
+
+    // poster thumbnail, until the one of the detail page
+    if opts.IncludePosterURL {
+        t.PosterURL = rowPoster (movieRec)
+    }
+
+    // weekend gross followed by the total gross
+    bo := &BoxOffice{}
+    grosses := boGrossRegexp.FindAllStringSubmatch(movieRec, 2)
+    if len (grosses) == 2 {
+        bo.WeekendGross = parseGross (grosses[0][1])
+        bo.TotalGross = parseGross (grosses[1][1])
+    } else {
+        failFields (errs, []string {"weekend_gross", "total_gross"}, "Could not obtain the grosses for", title)
+    }
+    if weeksMatch := boWeeksRegexp.FindStringSubmatch(movieRec); weeksMatch != nil {
+        bo.WeeksReleased, _ = strconv.Atoi (weeksMatch[1])
+    } else {
+        failField (errs, "weeks_released", "Could not obtain the weeks in release for", title)
+    }
+    t.BoxOffice = bo
+
+    // fetch summary, duration & genre once the row is parsed
+    // not needed for the lite output
+    if crawlInline() {
+        setDetails (t, fetchMoreInfo (ctx, moreInfoURL), errs)
+    }
+}
+
+// getBoxOfficeRating is the rating of the box-office chart layout. The chart has no
+// rating or votes, so there is nothing to extract.
+func getBoxOfficeRating (ctx context.Context, movieRec string, rate *float64, votes *uint64, errs *[]FieldError, wg *sync.WaitGroup) {
+    wg.Done()
+}
+
+// parseGross provides the gross in US dollars from its text e.g. $26.5M, 0 if the
+// text is not a gross.
+func parseGross (text string) uint64 {
+
+    m := grossRegexp.FindStringSubmatch(stripTags (text))
+    if m == nil {
+        return 0
+    }
+    amount, err := strconv.ParseFloat(strings.ReplaceAll(m[1], ",", ""), 64)
+    if err != nil {
+        return 0
+    }
+    return uint64(amount * grossUnits[m[2]] + 0.5)
+}
@@ -0,0 +1,71 @@
+/*
+ *-----------------------------------------------------------------
+ * IMDb Chart Fetcher - Tests of the box-office chart
+ *-----------------------------------------------------------------
+ */
+package imdb
+
+import (
+    "context"
+    "reflect"
+    "testing"
+)
+
+func TestLayoutFor (t *testing.T) {
+
+    tests := []struct {
+        pageUrl   string
+        titleData interface{}
+    }{
+        {chart_url_BoxOffice, getBoxOfficeTitleData},
+        {chart_url_BoxOffice + "/", getBoxOfficeTitleData},
+        {chart_url_BoxOffice + "?ref_=nv_ch_cht", getBoxOfficeTitleData},
+        {chart_url_BoxOffice + "/?ref_=nv_ch_cht", getBoxOfficeTitleData},
+        {search_url_Keyword + "?keywords=heist", getKeywordTitleData},
+        {chart_url_Tamil, getTitleData},
+        {imdb_url_Main + "/chart/top?ref_=chtbo_ql_3", getTitleData},
+    }
+
+    for _, tt := range tests {
+        got := reflect.ValueOf(layoutFor (tt.pageUrl).titleData).Pointer()
+        if want := reflect.ValueOf(tt.titleData).Pointer(); got != want {
+            t.Errorf ("layout of %s is not the expected one", tt.pageUrl)
+        }
+    }
+}
+
+func TestCrawlBoxOffice (t *testing.T) {
+
+    pages := detailPages (t)
+    pages[chart_url_BoxOffice] = fixture (t, "boxoffice.html")
+    configureTest (t, DefaultOptions(), pages)
+
+    chart, err := Crawl (context.Background(), chart_url_BoxOffice, 0)
+    if err != nil {
+        t.Fatal (err)
+    }
+
+    type movie struct {
+        id    string
+        title string
+        url   string
+        bo    BoxOffice
+        genre string
+    }
+    want := []movie {
+        {"tt0000001", "Nayakan", testURL_Detail1, BoxOffice{26500000, 1043100, 2}, "Crime, Drama"},
+        {"tt0000003", "Tom & Jerry", testURL_Detail3, BoxOffice{900000, 1200000000, 11}, "Thriller, Drama"},
+    }
+    if chart.Title != "Top Box Office (US)" || len (chart.Movies) != len (want) {
+        t.Fatalf ("chart %q with %d movies, want %d", chart.Title, len (chart.Movies), len (want))
+    }
+    for i, mov := range chart.Movies {
+        if mov.BoxOffice == nil {
+            t.Fatalf ("movie %d has no box-office figures", i)
+        }
+        got := movie{mov.IMDbID, mov.Title, mov.DetailURL, *mov.BoxOffice, mov.Genre}
+        if got != want[i] || len (mov.Errors) != 0 {
+            t.Errorf ("movie %d is %+v with errors %v, want %+v", i, got, mov.Errors, want[i])
+        }
+    }
+}
@@ -0,0 +1,97 @@
+/*
+ *-----------------------------------------------------------------
+ * IMDb Chart Fetcher - Cache
+ *-----------------------------------------------------------------
+ * Description: On-disk cache of the detail pages, so that a run
+ *              following another (e.g. during development) need not
+ *              fetch them again. The pages rarely change.
+ *              The raw body of each detail page is kept in the
+ *              directory given by -cache-dir, in a file named by the
+ *              SHA-256 of the canonical URL of the title, i.e.
+ *              https://www.imdb.com/title/tt0093603/ whatever the query
+ *              (e.g. ?ref_=) of the link crawled, so that the charts &
+ *              lists linking the same title share its page. A page is
+ *              served from there unless the file is older than
+ *              -cache-ttl, in which case it is fetched & stored afresh.
+ *              A page that cannot be stored is only warned about.
+ *              The hits & misses are counted, see metrics.go
+ *-----------------------------------------------------------------
+ */
+package imdb
+
+import (
+    "os"
+    "time"
+    "strings"
+    "io/ioutil"
+    "crypto/sha256"
+    "encoding/hex"
+    "path/filepath"
+)
+
+// cacheKey provides the URL the page is cached by, i.e. the detail page of its title
+// ID, or else the URL without its query.
+func cacheKey (pageUrl string) string {
+
+    if id := titleID (pageUrl); id != "" {
+        return idURL (id)
+    }
+    if queryIdx := strings.IndexAny(pageUrl, "?#"); queryIdx != -1 {
+        return pageUrl[ : queryIdx]
+    }
+    return pageUrl
+}
+
+// cachePath provides the path of the cache file of the URL.
+func cachePath (pageUrl string) string {
+
+    sum := sha256.Sum256 ([]byte(cacheKey (pageUrl)))
+    return filepath.Join (opts.CacheDir, hex.EncodeToString(sum[:]))
+}
+
+// cacheLoad provides the body of the page cached for the URL, if any & not older than
+// CacheTTL (of any age if CacheTTL is 0).
+func cacheLoad (pageUrl string) (string, bool) {
+
+    if opts.CacheDir == "" {
+        return "", false
+    }
+    path := cachePath (pageUrl)
+    stat, err := os.Stat (path)
+    if err != nil || (opts.CacheTTL > 0 && time.Since(stat.ModTime()) > opts.CacheTTL) {
+        cacheMisses.Add(1)
+        return "", false
+    }
+    data, err := ioutil.ReadFile (path)
+    if err != nil {
+        cacheMisses.Add(1)
+        return "", false
+    }
+    cacheHits.Add(1)
+    return string(data), true
+}
+
+// cacheStore keeps the body of the page of the URL in the cache. The file is written
+// aside & renamed into place, so that a run reading it meanwhile never sees it partly.
+func cacheStore (pageUrl, body string) {
+
+    if opts.CacheDir == "" {
+        return
+    }
+    tmp, err := ioutil.TempFile (opts.CacheDir, ".page-")
+    if err == nil {
+        _, err = tmp.WriteString (body)
+        if cerr := tmp.Close(); err == nil {
+            err = cerr
+        }
+        if err == nil {
+            err = os.Rename (tmp.Name(), cachePath (pageUrl))
+        }
+        if err != nil {
+            os.Remove (tmp.Name())
+        }
+    }
+    if err != nil {
+        warn ("ALARM", "Could not cache the page.", err)
+    }
+}
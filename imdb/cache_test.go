@@ -0,0 +1,77 @@
+/*
+ *-----------------------------------------------------------------
+ * IMDb Chart Fetcher - Tests of the cache
+ *-----------------------------------------------------------------
+ */
+package imdb
+
+import (
+    "context"
+    "testing"
+)
+
+func TestCacheKey (t *testing.T) {
+
+    tests := []struct {
+        pageUrl string
+        want    string
+    }{
+        {testURL_Detail1, testURL_Detail1},
+        {imdb_url_Main + "/title/tt0000001", testURL_Detail1},
+        {imdb_url_Main + "/title/tt0000001/?ref_=chttp_t_1", testURL_Detail1},
+        {imdb_url_Main + "/title/tt0000001/?ref_=kw_li_tt", testURL_Detail1},
+        {"https://example.com/movie/?ref_=x", "https://example.com/movie/"},
+    }
+
+    for _, tt := range tests {
+        if got := cacheKey (tt.pageUrl); got != tt.want {
+            t.Errorf ("cacheKey(%q) = %q, want %q", tt.pageUrl, got, tt.want)
+        }
+    }
+}
+
+func TestCacheHits (t *testing.T) {
+
+    pages := map[string]string {
+        testURL_Detail1 + "?ref_=chttp_t_1": fixture (t, "detail1.html"),
+        testURL_Detail1 + "?ref_=kw_li_tt":  fixture (t, "detail1.html"),
+    }
+    f := &countingFetcher{Fetcher: MapFetcher (pages), count: map[string]int {}}
+    o := DefaultOptions()
+    o.CacheDir = t.TempDir()
+    configureFetcher (t, o, f)
+
+    // the same title as linked by a chart & by a keyword search
+    before := ReadStats()
+    first := fetchMoreInfo (context.Background(), testURL_Detail1 + "?ref_=chttp_t_1")
+    second := fetchMoreInfo (context.Background(), testURL_Detail1 + "?ref_=kw_li_tt")
+    if first.Summary == "" || second.Summary != first.Summary {
+        t.Errorf ("summaries %q & %q", first.Summary, second.Summary)
+    }
+    if n := f.count[testURL_Detail1 + "?ref_=kw_li_tt"]; n != 0 {
+        t.Errorf ("the cached page fetched %d times", n)
+    }
+
+    after := ReadStats()
+    if hits, misses := after.CacheHits - before.CacheHits, after.CacheMisses - before.CacheMisses; hits != 1 || misses != 1 {
+        t.Errorf ("%d hits & %d misses, want 1 & 1", hits, misses)
+    }
+}
+
+func TestCacheHitRatio (t *testing.T) {
+
+    tests := []struct {
+        stats Stats
+        want  float64
+    }{
+        {Stats{}, 0},
+        {Stats{CacheHits: 3, CacheMisses: 1}, 0.75},
+        {Stats{CacheMisses: 2}, 0},
+    }
+
+    for _, tt := range tests {
+        if got := tt.stats.CacheHitRatio(); got != tt.want {
+            t.Errorf ("ratio of %+v is %v, want %v", tt.stats, got, tt.want)
+        }
+    }
+}
@@ -0,0 +1,113 @@
+/*
+ *-----------------------------------------------------------------
+ * IMDb Chart Fetcher - Credits
+ *-----------------------------------------------------------------
+ * Description: Directors & stars of the title, taken from the
+ *              structured data (JSON-LD) of the detail page, where
+ *              a person or a list of persons is given as:
+ *                "director": {"@type": "Person", "name": "..."}
+ *                "actor": [{"@type": "Person", "name": "..."}, ...]
+ *              The pages without the structured data have them in the
+ *              credit summary of the old layout instead:
+ *                <div class="credit_summary_item">
+ *                  <h4 class="inline">Directors:</h4>
+ *                  <a href="/name/nm...">...</a>, <a href="/name/nm...">...</a>
+ *                </div>
+ *              with Director/Directors & Star/Stars as the labels.
+ *              The principal cast, beyond the few stars, is taken from
+ *              the cast list of the page, as of the current layout:
+ *                <a data-testid="title-cast-item__actor" href="/name/nm...">...</a>
+ *              or of the old one:
+ *                <table class="cast_list"> ... <td><a href="/name/nm...">...</a></td>
+ *-----------------------------------------------------------------
+ */
+package imdb
+
+import (
+    "html"
+    "regexp"
+    "strings"
+    "encoding/json"
+)
+
+// selectors of the credit summary of the old layout
+var (
+    creditItemRegexp   = regexp.MustCompile (`(?s)<div class="credit_summary_item">\s*<h4 class="inline">([^<:]*):</h4>(.*?)</div>`)
+    creditPersonRegexp = regexp.MustCompile (`<a href="/name/[^"]*"[^>]*>([^<]*)</a>`)
+)
+
+// selectors of the cast list, of the current layout & of the old one
+var (
+    castActorRegexp  = regexp.MustCompile (`<a data-testid="title-cast-item__actor" href="/name/[^"]*"[^>]*>([^<]*)</a>`)
+    castListRegexp   = regexp.MustCompile (`(?s)<table class="cast_list">(.*?)</table>`)
+    castPersonRegexp = regexp.MustCompile (`<td>\s*<a href="/name/[^"]*"[^>]*>([^<]*)</a>`)
+)
+
+// ldPeople is the names of the persons of the structured data, given either as one
+// person or as a list of persons.
+type ldPeople []string
+
+func (p *ldPeople) UnmarshalJSON (data []byte) error {
+
+    type person struct {
+        Name string `json:"name"`
+    }
+
+    var persons []person
+    if err := json.Unmarshal (data, &persons); err != nil {
+        var one person
+        if err := json.Unmarshal (data, &one); err != nil {
+            return err
+        }
+        persons = []person{one}
+    }
+
+    for _, prs := range persons {
+        if prs.Name != "" {
+            *p = append (*p, html.UnescapeString(prs.Name))
+        }
+    }
+    return nil
+}
+
+// creditSummary provides the directors & the stars from the credit summary of the
+// detail page.
+func creditSummary (respBody string) ([]string, []string) {
+
+    var directors, stars []string
+
+    for _, item := range creditItemRegexp.FindAllStringSubmatch(respBody, -1) {
+        names := []string {}
+        for _, m := range creditPersonRegexp.FindAllStringSubmatch(item[2], -1) {
+            names = append (names, html.UnescapeString(strings.TrimSpace(m[1])))
+        }
+
+        switch strings.TrimSpace(item[1]) {
+        case "Director", "Directors":
+            directors = names
+        case "Star", "Stars":
+            stars = names
+        }
+    }
+    return directors, stars
+}
+
+// castList provides the names of the principal cast from the cast list of the detail
+// page, empty if the page has none.
+func castList (respBody string) []string {
+
+    matches := castActorRegexp.FindAllStringSubmatch(respBody, -1)
+    if len (matches) == 0 {
+        if list := castListRegexp.FindStringSubmatch(respBody); list != nil {
+            matches = castPersonRegexp.FindAllStringSubmatch(list[1], -1)
+        }
+    }
+
+    cast := []string {}
+    for _, m := range matches {
+        if name := html.UnescapeString(strings.TrimSpace(m[1])); name != "" {
+            cast = append (cast, name)
+        }
+    }
+    return cast
+}
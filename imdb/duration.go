@@ -0,0 +1,80 @@
+/*
+ *-----------------------------------------------------------------
+ * IMDb Chart Fetcher - Duration
+ *-----------------------------------------------------------------
+ * Description: IMDb renders the runtime of a movie in various forms
+ *              across the pages, e.g. "126 min", "2h 6min", "2h 6m"
+ *              or just "2h", while the structured data (JSON-LD) has
+ *              it in the ISO 8601 form e.g. "PT2H6M". The runtime is
+ *              parsed into minutes so that it can be presented
+ *              uniformly as "2h 6m". A runtime in none of the forms
+ *              leaves the minutes 0 & is named in the errors of the
+ *              movie as duration_minutes.
+ *-----------------------------------------------------------------
+ */
+package imdb
+
+import (
+    "fmt"
+    "regexp"
+    "strings"
+    "strconv"
+)
+
+// hours and/or minutes as rendered by IMDb, & as in the ISO 8601 duration
+var (
+    durationRegexp    = regexp.MustCompile (`^(?:(\d+)\s*h)?\s*(?:(\d+)\s*m(?:in)?)?$`)
+    isoDurationRegexp = regexp.MustCompile (`^PT(?:(\d+)H)?(?:(\d+)M)?(?:\d+S)?$`)
+)
+
+// field of the runtime in minutes, for the errors of the movie
+const field_DurationMinutes = `duration_minutes`
+
+// durationMinutes parses the runtime text into the number of minutes. False is
+// provided if the text is not in any of the known forms.
+func durationMinutes (text string) (int, bool) {
+
+    text = strings.TrimSpace(text)
+    m := durationRegexp.FindStringSubmatch(text)
+    if m == nil {
+        m = isoDurationRegexp.FindStringSubmatch(text)
+    }
+    if m == nil || (m[1] == "" && m[2] == "") {
+        return 0, false
+    }
+
+    hours, _ := strconv.Atoi (m[1])
+    mins, _ := strconv.Atoi (m[2])
+    return hours * 60 + mins, true
+}
+
+// checkDuration appends the minutes of the runtime to errs if the details have the
+// runtime but it could not be parsed.
+func checkDuration (d MovDetail, errs *[]FieldError) {
+    if d.Duration != "" && d.DurationMinutes == 0 {
+        *errs = append (*errs, FieldError{field_DurationMinutes, fmt.Sprintf ("Could not parse the duration %q", d.Duration)})
+    }
+}
+
+// formatMinutes provides the uniform display form of the runtime e.g. "2h 6m".
+func formatMinutes (mins int) string {
+
+    switch {
+    case mins < 60:
+        return fmt.Sprintf ("%dm", mins)
+    case mins % 60 == 0:
+        return fmt.Sprintf ("%dh", mins / 60)
+    }
+    return fmt.Sprintf ("%dh %dm", mins / 60, mins % 60)
+}
+
+// prettyDuration provides the uniform display form of the runtime text, or the
+// text as is when it cannot be parsed.
+func prettyDuration (text string) string {
+
+    mins, ok := durationMinutes (text)
+    if !ok {
+        return text
+    }
+    return formatMinutes (mins)
+}
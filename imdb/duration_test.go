@@ -0,0 +1,80 @@
+/*
+ *-----------------------------------------------------------------
+ * IMDb Chart Fetcher - Tests of the duration
+ *-----------------------------------------------------------------
+ */
+package imdb
+
+import (
+    "testing"
+)
+
+func TestDurationMinutes (t *testing.T) {
+
+    tests := []struct {
+        text   string
+        mins   int
+        ok     bool
+        pretty string
+    }{
+        {"126 min", 126, true, "2h 6m"},
+        {"126min", 126, true, "2h 6m"},
+        {"2h 6min", 126, true, "2h 6m"},
+        {"2h 6m", 126, true, "2h 6m"},
+        {"2h6m", 126, true, "2h 6m"},
+        {" 2h 6m\n", 126, true, "2h 6m"},
+        {"2h", 120, true, "2h"},
+        {"45m", 45, true, "45m"},
+        {"PT2H6M", 126, true, "2h 6m"},
+        {"PT2H", 120, true, "2h"},
+        {"PT45M", 45, true, "45m"},
+        {"PT2H6M30S", 126, true, "2h 6m"},
+        {"", 0, false, ""},
+        {"PT", 0, false, "PT"},
+        {"two hours", 0, false, "two hours"},
+        {"2 hours 6 minutes", 0, false, "2 hours 6 minutes"},
+    }
+
+    for _, tt := range tests {
+        mins, ok := durationMinutes (tt.text)
+        if mins != tt.mins || ok != tt.ok {
+            t.Errorf ("durationMinutes(%q) = %d, %v, want %d, %v", tt.text, mins, ok, tt.mins, tt.ok)
+        }
+        if got := prettyDuration (tt.text); got != tt.pretty {
+            t.Errorf ("prettyDuration(%q) = %q, want %q", tt.text, got, tt.pretty)
+        }
+    }
+}
+
+func TestFormatMinutes (t *testing.T) {
+
+    tests := map[int]string {
+        0:   "0m",
+        59:  "59m",
+        60:  "1h",
+        61:  "1h 1m",
+        126: "2h 6m",
+        180: "3h",
+    }
+
+    for mins, want := range tests {
+        if got := formatMinutes (mins); got != want {
+            t.Errorf ("formatMinutes(%d) = %q, want %q", mins, got, want)
+        }
+    }
+}
+
+func TestCheckDuration (t *testing.T) {
+
+    var errs []FieldError
+    checkDuration (MovDetail{Duration: "2h 6m", DurationMinutes: 126}, &errs)
+    checkDuration (MovDetail{}, &errs)
+    if len (errs) != 0 {
+        t.Fatalf ("errors %v, want none", errs)
+    }
+
+    checkDuration (MovDetail{Duration: "two hours"}, &errs)
+    if len (errs) != 1 || errs[0].Field != field_DurationMinutes {
+        t.Errorf ("errors %v, want %s", errs, field_DurationMinutes)
+    }
+}
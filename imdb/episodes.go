@@ -0,0 +1,127 @@
+/*
+ *-----------------------------------------------------------------
+ * IMDb Chart Fetcher - TV Episodes
+ *-----------------------------------------------------------------
+ * Description: Crawls the episodes of a TV series from its episodes
+ *              page, e.g. https://www.imdb.com/title/tt0000002/episodes
+ *              The seasons are listed in the season selector of the
+ *              page & each season has its own page (?season=N) with
+ *              one item per episode, having the season & episode
+ *              number (S1, Ep1), air date, title & rating.
+ *              This takes one request per season of every series &
+ *              hence is only done when asked for (-tv-episodes).
+ *-----------------------------------------------------------------
+ */
+package imdb
+
+import (
+    "html"
+    "regexp"
+    "context"
+    "strings"
+    "strconv"
+)
+
+// Structure to maintain the details of an episode of a TV series
+// facilitates easy conversion from structure to json by using the meta-fields
+type EpisodeInfo struct {
+    Title   string  `json:"title"`
+    Season  int     `json:"season"`
+    Number  int     `json:"episode"`
+    AirDate string  `json:"air_date"`
+    Rating  float64 `json:"imdb_rating"`
+}
+
+// selectors of the episodes page
+var (
+    seasonSelectRegexp = regexp.MustCompile (`(?s)<select id="bySeason"[^>]*>(.*?)</select>`)
+    seasonOptionRegexp = regexp.MustCompile (`<option[^>]*value="(\d+)"`)
+    epNumberRegexp     = regexp.MustCompile (`S(\d+), Ep(\d+)`)
+    epMetaNumberRegexp = regexp.MustCompile (`itemprop="episodeNumber" content="(\d+)"`)
+    epTitleRegexp      = regexp.MustCompile (`itemprop="name">([^<]*)</a>`)
+    epAirDateRegexp    = regexp.MustCompile (`<div class="airdate">\s*([^<]*?)\s*</div>`)
+    epRatingRegexp     = regexp.MustCompile (`ipl-rating-star__rating">([\d.]+)<`)
+)
+
+// crawlEpisodes crawls all the episodes of the TV series having the given detail
+// URL, season by season. The episodes obtained till a failure are provided.
+// The episodes page is that of the title ID, as the detail URL may have a query
+// (e.g. ?ref_=) which the path is not to be appended to.
+func crawlEpisodes (ctx context.Context, seriesUrl string) []EpisodeInfo {
+
+    id := titleID (seriesUrl)
+    if id == "" {
+        warn ("FAILURE", "Could not find the title ID of the series", seriesUrl)
+        return nil
+    }
+    episodesUrl := episodesURL (id)
+
+    respBody, err := fetcher.Get (ctx, episodesUrl)
+    if err != nil {
+        warn ("FAILURE", "Could not fetch the episodes.", err)
+        return nil
+    }
+
+    episodes := []EpisodeInfo {}
+    for _, season := range episodeSeasons (respBody) {
+        seasonBody, err := fetcher.Get (ctx, episodesUrl + "?season=" + strconv.Itoa(season))
+        if err != nil {
+            warn ("FAILURE", "Could not fetch the episodes of season", season, err)
+            break
+        }
+        episodes = append (episodes, parseEpisodes (seasonBody, season)...)
+    }
+    return episodes
+}
+
+// episodesURL provides the episodes page of the series having the given ID.
+func episodesURL (id string) string {
+    return idURL (id) + "episodes"
+}
+
+// episodeSeasons provides the seasons listed in the season selector of the episodes
+// page. Seasons without a number (unknown) are left out.
+func episodeSeasons (respBody string) []int {
+
+    seasons := []int {}
+
+    selectMatch := seasonSelectRegexp.FindStringSubmatch(respBody)
+    if selectMatch == nil {
+        return seasons
+    }
+    for _, opt := range seasonOptionRegexp.FindAllStringSubmatch(selectMatch[1], -1) {
+        season, _ := strconv.Atoi (opt[1])
+        seasons = append (seasons, season)
+    }
+    return seasons
+}
+
+// parseEpisodes parses the episodes from the page of the given season.
+func parseEpisodes (respBody string, season int) []EpisodeInfo {
+
+    episodes := []EpisodeInfo {}
+
+    items := strings.Split(respBody, `<div class="list_item`)
+    for _, item := range items[1:] {
+        ep := EpisodeInfo{Season: season}
+
+        // S1, Ep1 else the episode number alone
+        if m := epNumberRegexp.FindStringSubmatch(item); m != nil {
+            ep.Season, _ = strconv.Atoi (m[1])
+            ep.Number, _ = strconv.Atoi (m[2])
+        } else if m := epMetaNumberRegexp.FindStringSubmatch(item); m != nil {
+            ep.Number, _ = strconv.Atoi (m[1])
+        }
+        if m := epTitleRegexp.FindStringSubmatch(item); m != nil {
+            ep.Title = html.UnescapeString(strings.TrimSpace(m[1]))
+        }
+        if m := epAirDateRegexp.FindStringSubmatch(item); m != nil {
+            ep.AirDate = m[1]
+        }
+        if m := epRatingRegexp.FindStringSubmatch(item); m != nil {
+            ep.Rating, _ = strconv.ParseFloat(m[1], 64)
+        }
+        episodes = append (episodes, ep)
+    }
+    return episodes
+}
@@ -0,0 +1,45 @@
+/*
+ *-----------------------------------------------------------------
+ * IMDb Chart Fetcher - Tests of the TV episodes
+ *-----------------------------------------------------------------
+ */
+package imdb
+
+import (
+    "context"
+    "testing"
+)
+
+func TestCrawlEpisodes (t *testing.T) {
+
+    seriesPages := map[string]string {
+        imdb_url_Main + "/title/tt0000002/episodes":          fixture (t, "episodes.html"),
+        imdb_url_Main + "/title/tt0000002/episodes?season=1": fixture (t, "episodes1.html"),
+        imdb_url_Main + "/title/tt0000002/episodes?season=2": fixture (t, "episodes2.html"),
+    }
+    tests := []struct {
+        name      string
+        seriesUrl string
+        episodes  int
+    }{
+        {"detail URL", imdb_url_Main + "/title/tt0000002/", 4},
+        {"without the trailing slash", imdb_url_Main + "/title/tt0000002", 4},
+        {"with the query of the chart", imdb_url_Main + "/title/tt0000002/?ref_=chttp_t_2", 4},
+        {"no title ID", imdb_url_Main + "/chart/top", 0},
+    }
+
+    configureTest (t, DefaultOptions(), seriesPages)
+    for _, tt := range tests {
+        t.Run (tt.name, func (t *testing.T) {
+            episodes := crawlEpisodes (context.Background(), tt.seriesUrl)
+            if len (episodes) != tt.episodes {
+                t.Fatalf ("%d episodes, want %d", len (episodes), tt.episodes)
+            }
+            for _, ep := range episodes {
+                if ep.Title == "" || ep.Season == 0 || ep.Number == 0 {
+                    t.Errorf ("incomplete episode %+v", ep)
+                }
+            }
+        })
+    }
+}
@@ -0,0 +1,93 @@
+/*
+ *-----------------------------------------------------------------
+ * IMDb Chart Fetcher - Genre Buckets
+ *-----------------------------------------------------------------
+ * Description: IMDb has about 25 genres, which are collapsed into a
+ *              handful of buckets for dashboards & grouping, e.g.
+ *              Action, Adventure & Thriller all go to "Action".
+ *              The built-in mapping can be replaced by a JSON file
+ *              of {"genre": "bucket"} given via -genre-map. Genres
+ *              not in the mapping go to "Other".
+ *              The raw genres are always kept as is.
+ *-----------------------------------------------------------------
+ */
+package imdb
+
+import (
+    "strings"
+    "io/ioutil"
+    "encoding/json"
+)
+
+// bucket for the genres not present in the mapping
+const (
+    genreBucket_Other = `Other`
+)
+
+// built-in mapping of the IMDb genres to the buckets
+var defaultGenreBuckets = map[string]string {
+    "Action":      "Action",
+    "Adventure":   "Action",
+    "Thriller":    "Action",
+    "War":         "Action",
+    "Western":     "Action",
+    "Drama":       "Drama",
+    "Crime":       "Drama",
+    "Romance":     "Drama",
+    "Biography":   "Drama",
+    "History":     "Drama",
+    "Film-Noir":   "Drama",
+    "Sport":       "Drama",
+    "Comedy":      "Comedy",
+    "Musical":     "Comedy",
+    "Music":       "Comedy",
+    "Family":      "Family",
+    "Animation":   "Family",
+    "Fantasy":     "Fantasy",
+    "Sci-Fi":      "Fantasy",
+    "Horror":      "Horror",
+    "Mystery":     "Horror",
+    "Documentary": "Documentary",
+    "News":        "Documentary",
+    "Reality-TV":  "Documentary",
+    "Talk-Show":   "Documentary",
+    "Game-Show":   "Documentary",
+}
+
+// mapping of the genres to the buckets in use
+var genreBuckets = defaultGenreBuckets
+
+// LoadGenreBuckets reads the mapping of the genres to the buckets from the JSON file.
+func LoadGenreBuckets (path string) (map[string]string, error) {
+
+    data, err := ioutil.ReadFile (path)
+    if err != nil {
+        return nil, err
+    }
+
+    buckets := map[string]string {}
+    if err := json.Unmarshal (data, &buckets); err != nil {
+        return nil, err
+    }
+    return buckets, nil
+}
+
+// collapseGenres maps the genres to their buckets, each bucket being present only
+// once, in the order of the genres.
+func collapseGenres (genres []string) string {
+
+    collapsed := []string {}
+    seen := map[string]bool {}
+
+    for _, genre := range genres {
+        bucket, ok := genreBuckets[strings.TrimSpace(genre)]
+        if !ok {
+            bucket = genreBucket_Other
+        }
+        if !seen[bucket] {
+            seen[bucket] = true
+            collapsed = append (collapsed, bucket)
+        }
+    }
+    return strings.Join(collapsed, ", ")
+}
@@ -0,0 +1,164 @@
+/*
+ *-----------------------------------------------------------------
+ * IMDb Chart Fetcher - List of IDs
+ *-----------------------------------------------------------------
+ * Description: Layout of a list of IMDb title IDs (tconst) given via
+ *              -ids-from (see ParseIDs & CrawlIDs), one per line, e.g.
+ *                tt0093603
+ *                https://www.imdb.com/title/tt0367495/
+ *              Blank lines & lines starting with # are skipped.
+ *              There is no chart to parse, so the detail page of each
+ *              ID is fetched for the title & release year along with
+ *              the summary, duration & genre, same as for the charts,
+ *              & for the rating & the number of votes as well, the
+ *              page being fetched (or loaded from the cache) once for
+ *              both. The output is the same as that of a chart.
+ *-----------------------------------------------------------------
+ */
+package imdb
+
+import (
+    "fmt"
+    "html"
+    "sync"
+    "time"
+    "context"
+    "strings"
+)
+
+// idPage is the detail page of an ID, fetched once for both the title & the rating.
+type idPage struct {
+    once sync.Once
+    body string
+    err  error
+}
+
+// idPages are the detail pages of the IDs of a crawl, by ID.
+type idPages struct {
+    mu    sync.Mutex
+    pages map[string]*idPage
+}
+
+// newIDsLayout provides the layout of a list of IDs, where each movie is a line of
+// the list, for a crawl of its own. The title & the rating of a movie share its
+// detail page.
+func newIDsLayout () listLayout {
+    p := &idPages{pages: map[string]*idPage {}}
+    return listLayout{idsList, ParseIDs, p.titleData, p.rating}
+}
+
+// get provides the detail page of the title having the given ID, obtained via the
+// cache or the fetcher on the first call for the ID only.
+func (p *idPages) get (ctx context.Context, id string) (string, error) {
+
+    p.mu.Lock()
+    page, ok := p.pages[id]
+    if !ok {
+        page = &idPage{}
+        p.pages[id] = page
+    }
+    p.mu.Unlock()
+
+    page.once.Do (func (){
+        page.body, page.err = detailPage (ctx, idURL (id))
+    })
+    return page.body, page.err
+}
+
+// idsList provides the list as is, the whole file being the list of IDs.
+func idsList (page string) string {
+    return page
+}
+
+// ParseIDs splits the list into the IDs, one per line, skipping the blank lines,
+// the comments & the lines without an ID.
+func ParseIDs (list string) []string {
+
+    ids := []string {}
+    for _, line := range strings.Split(list, "\n") {
+        line = strings.TrimSpace(line)
+        if line == "" || strings.HasPrefix (line, "#") {
+            continue
+        }
+        if id := titleID (line); id != "" {
+            ids = append (ids, id)
+        } else {
+            warn ("FAILURE", "Could not find an IMDb title ID in", line)
+        }
+    }
+    return ids
+}
+
+// idURL provides the detail page of the title having the given ID.
+func idURL (id string) string {
+    return imdb_url_Main + "/title/" + id + "/"
+}
+
+// titleData is triggered as a goroutine and it obtains the detail page of the title
+// having the given ID. The title & release year are parsed from the structured data
+// of the page while the summary, genre & duration are parsed as by the crawler.
+func (p *idPages) titleData (ctx context.Context, id string, t *TitleData, errs *[]FieldError, wg *sync.WaitGroup) {
+
+    defer wg.Done()
+
+    moreInfoURL := idURL (id)
+    t.DetailURL = moreInfoURL
+    t.IMDbID = id
+
+    respBody, err := p.get (ctx, id)
+    if err != nil {
+        failField (errs, field_Title, "Could not fetch the title", id, err)
+        return
+    }
+
+    // title & release year e.g. "datePublished": "1987-10-21"
+    ld := extractJSONLD (respBody)
+    t.Title = html.UnescapeString(ld.Name)
+    if len (ld.DatePublished) < 4 {
+        err = fmt.Errorf ("No datePublished in the structured data")
+    } else {
+        t.ReleaseYear, err = parseYear (ld.DatePublished[ : 4])
+    }
+    if err != nil {
+        failField (errs, field_ReleaseYear, "Could not obtain release year for", id, err)
+    }
+
+    // not needed for the lite output
+    if crawlInline() {
+        setDetails (t, parseMoreInfo (ctx, moreInfoURL, respBody), errs)
+    }
+}
+
+// rating obtains the detail page of the title having the given ID for its rating &
+// number of votes. Titles that are yet to be released have none, which is not a
+// failure.
+func (p *idPages) rating (ctx context.Context, id string, rate *float64, votes *uint64, errs *[]FieldError, wg *sync.WaitGroup) {
+
+    defer wg.Done()
+
+    respBody, err := p.get (ctx, id)
+    if err != nil {
+        failField (errs, field_Rating, "Could not fetch the rating of", id, err)
+        return
+    }
+
+    ld := extractJSONLD (respBody)
+    if ld.AggregateRating.RatingValue == 0 {
+        if !released (ld) {
+            debug ("No rating of", id, "yet to be released")
+        } else {
+            failField (errs, field_Rating, "Could not obtain rating")
+        }
+    }
+    *rate = ld.AggregateRating.RatingValue
+    *votes = ld.AggregateRating.RatingCount
+}
+
+// released tells whether the title has been released, i.e. the structured data has
+// the date of its release & the date has come. The titles yet to be released have
+// the date to come or none at all.
+func released (ld ldData) bool {
+
+    date, err := time.Parse ("2006-01-02", ld.DatePublished)
+    return err == nil && !date.After (time.Now())
+}
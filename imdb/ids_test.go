@@ -0,0 +1,135 @@
+/*
+ *-----------------------------------------------------------------
+ * IMDb Chart Fetcher - Tests of the list of IDs
+ *-----------------------------------------------------------------
+ */
+package imdb
+
+import (
+    "fmt"
+    "sync"
+    "time"
+    "context"
+    "reflect"
+    "testing"
+)
+
+// countingFetcher is the Fetcher counting the requests of each URL, the pages being
+// served by the Fetcher it wraps.
+type countingFetcher struct {
+    Fetcher
+    mu    sync.Mutex
+    count map[string]int
+}
+
+func (c *countingFetcher) Get (ctx context.Context, url string) (string, error) {
+
+    c.mu.Lock()
+    c.count[url]++
+    c.mu.Unlock()
+    return c.Fetcher.Get (ctx, url)
+}
+
+func TestParseIDs (t *testing.T) {
+
+    list := "# watchlist\n\ntt0000001\n  https://www.imdb.com/title/tt0000002/?ref_=x  \nno ID here\ntt00000031\n"
+    want := []string {"tt0000001", "tt0000002", "tt00000031"}
+    if got := ParseIDs (list); !reflect.DeepEqual (got, want) {
+        t.Errorf ("IDs %v, want %v", got, want)
+    }
+}
+
+func TestCrawlIDs (t *testing.T) {
+
+    f := &countingFetcher{Fetcher: MapFetcher (detailPages (t)), count: map[string]int {}}
+    configureFetcher (t, DefaultOptions(), f)
+
+    chart, err := CrawlIDs (context.Background(), []string {"tt0000001", "tt0000003"})
+    if err != nil {
+        t.Fatal (err)
+    }
+
+    type movie struct {
+        rank   int
+        id     string
+        title  string
+        year   uint64
+        rating float64
+        votes  uint64
+        genre  string
+    }
+    want := []movie {
+        {1, "tt0000001", "Movie 1", 1981, 8.1, 12345, "Crime, Drama"},
+        {2, "tt0000003", "Movie 3", 1983, 8.3, 32345, "Thriller, Drama"},
+    }
+    if len (chart.Movies) != len (want) {
+        t.Fatalf ("%d movies, want %d", len (chart.Movies), len (want))
+    }
+    for i, mov := range chart.Movies {
+        got := movie{mov.Rank, mov.IMDbID, mov.Title, mov.ReleaseYear, mov.Rating, mov.Votes, mov.Genre}
+        if got != want[i] || len (mov.Errors) != 0 {
+            t.Errorf ("movie %d is %+v with errors %v, want %+v", i, got, mov.Errors, want[i])
+        }
+    }
+
+    // the detail page of each ID once, for both the title & the rating
+    for _, pageUrl := range []string {testURL_Detail1, testURL_Detail3} {
+        if f.count[pageUrl] != 1 {
+            t.Errorf ("%s fetched %d times, want once", pageUrl, f.count[pageUrl])
+        }
+    }
+}
+
+func TestCrawlIDsCached (t *testing.T) {
+
+    f := &countingFetcher{Fetcher: MapFetcher (detailPages (t)), count: map[string]int {}}
+    o := DefaultOptions()
+    o.CacheDir = t.TempDir()
+    configureFetcher (t, o, f)
+
+    // the second crawl is served from the cache of the first
+    for run := 0; run < 2; run++ {
+        chart, err := CrawlIDs (context.Background(), []string {"tt0000002"})
+        if err != nil {
+            t.Fatal (err)
+        }
+        if mov := chart.Movies[0]; mov.Title != "Movie 2" || mov.Rating != 8.2 {
+            t.Errorf ("run %d: title %q & rating %v", run, mov.Title, mov.Rating)
+        }
+    }
+    if f.count[testURL_Detail2] != 1 {
+        t.Errorf ("%s fetched %d times, want once", testURL_Detail2, f.count[testURL_Detail2])
+    }
+}
+
+// the lines without an ID & the released titles without a rating are failures, the
+// titles yet to be released having no rating as expected
+func TestCrawlIDsFailures (t *testing.T) {
+
+    ldPage := func (date string) string {
+        return `<html><head><script type="application/ld+json">{"@type":"Movie","name":"Movie","datePublished":"` + date + `"}</script></head></html>`
+    }
+    o := DefaultOptions()
+    o.Details = false
+    configureTest (t, o, map[string]string {
+        idURL ("tt0000004"): ldPage (fmt.Sprintf ("%d-01-01", time.Now().Year() + 1)),
+        idURL ("tt0000005"): ldPage ("1987-10-21"),
+    })
+
+    chart, err := CrawlIDs (context.Background(), []string {"tt0000004", "no ID here", "tt0000005", ""})
+    if err != nil {
+        t.Fatal (err)
+    }
+    if len (chart.Movies) != 2 || chart.AvailableCount != 2 {
+        t.Fatalf ("%d of %d movies, want 2 of 2", len (chart.Movies), chart.AvailableCount)
+    }
+    if errs := chart.Movies[0].Errors; len (errs) != 0 {
+        t.Errorf ("unreleased title has errors %v", errs)
+    }
+    if errs := chart.Movies[1].Errors; !reflect.DeepEqual (errs, []string {field_Rating}) {
+        t.Errorf ("released title has errors %v, want %v", errs, []string {field_Rating})
+    }
+    if chart.Failures != 2 {
+        t.Errorf ("%d failures, want 2", chart.Failures)
+    }
+}
@@ -0,0 +1,1668 @@
+/*
+ *-----------------------------------------------------------------
+ * IMDb Chart Fetcher - Crawler
+ *-----------------------------------------------------------------
+ * Description: Package imdb scrapes the list of movies of an IMDb
+ *              chart (or keyword search, or list of title IDs) &
+ *              crawls the detail page of each movie, providing the
+ *              movies as ImdbChartData for the caller to marshal or
+ *              process as it likes, e.g.
+ *
+ *                movies, err := imdb.FetchChart (ctx, url, 10)
+ *
+ *              The crawl is set up via Configure with the Options,
+ *              the defaults being those of the command-line program.
+ *              The options are package wide, so the crawls done
+ *              concurrently share them.
+ *              The command-line program (imdb_chart_fetcher) is a
+ *              thin wrapper of the package. See ../main.go
+ *-----------------------------------------------------------------
+ */
+package imdb
+
+import (
+    "io"
+    "fmt"
+    "html"
+    "sort"
+    "errors"
+    "sync"
+    "time"
+    "regexp"
+    "context"
+    "strings"
+    "strconv"
+    "unicode"
+    "math/rand"
+    "sync/atomic"
+    "net/url"
+    "net/http"
+    "io/ioutil"
+    "compress/gzip"
+    "crypto/tls"
+    "crypto/x509"
+    "encoding/json"
+)
+
+// IMDB URL constants for web crawling/scraping
+const (
+    imdb_url_Main       = `https://www.imdb.com`
+    chart_url_Indian    = `https://www.imdb.com/india/top-rated-indian-movies`
+    chart_url_Tamil     = `https://www.imdb.com/india/top-rated-tamil-movies`
+    chart_url_Telugu    = `https://www.imdb.com/india/top-rated-telugu-movies`
+    search_url_Keyword  = `https://www.imdb.com/search/keyword`
+    chart_url_BoxOffice = `https://www.imdb.com/chart/boxoffice`
+    path_BoxOffice      = `/chart/boxoffice`
+    imdb_Host           = `imdb.com`
+)
+
+// charts selectable by their name instead of the URL, see ChartURL
+var namedCharts = map[string]string {
+    "indian":    chart_url_Indian,
+    "tamil":     chart_url_Tamil,
+    "telugu":    chart_url_Telugu,
+    "boxoffice": chart_url_BoxOffice,
+}
+
+// User-Agent of the requests to IMDb unless given otherwise, that of a browser as
+// IMDb serves a different layout or a 403 to the clients not looking like one
+const (
+    default_UserAgent = `Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36`
+)
+
+// HTML element classes used as selectors to find the element
+const (
+    td_titleClass     = `titleColumn`
+    td_ratingClass    = `ratingColumn imdbRating`
+    releaseYear_class = `secondaryInfo`
+    summary_class     = `summary_text`
+)
+
+// plot summary page of a title, relative to its detail page & the summaries on it
+const (
+    plotSummary_path = `/plotsummary`
+)
+var plotSummaryRegexp = regexp.MustCompile (`(?s)<li class="ipl-zebra-list__item" id="summary-[^"]*">\s*<p>(.*?)</p>`)
+
+// structured data (JSON-LD) as embedded in the IMDb detail page
+const (
+    jsonLD_script = `<script type="application/ld+json">`
+)
+
+// links of the genres on the detail page, to the search of the titles of the genre
+// e.g. <a href="/search/title?genres=drama&explore=...">Drama</a>, wherever they are
+// on the metadata line
+var genreLinkRegexp = regexp.MustCompile (`(?s)<a\b[^>]*\bhref="[^"]*/search/title/?\?(?:[^"]*&(?:amp;)?)?genres=[^"]*"[^>]*>(.*?)</a>`)
+
+// fields of the list records which may fail to be parsed, as named in the JSON
+const (
+    field_Title       = `title`
+    field_ReleaseYear = `movie_release_year`
+    field_Rating      = `imdb_rating`
+    field_Votes       = `votes`
+)
+
+// the detail page, for the errors of the movie when it could not be fetched at all
+const field_Details = `details`
+
+// HTML tag, opening or closing, nested in the text
+var htmlTagRegexp = regexp.MustCompile (`<[^>]*>`)
+
+// start of a table row, splitting the chart table into its rows
+var tableRowRegexp = regexp.MustCompile (`<tr>*`)
+
+// heading cell of a table row, telling the header row from those of the movies
+var tableHeadingRegexp = regexp.MustCompile (`<th[\s>]`)
+
+// number of votes as mentioned in the title of the rating
+// e.g. title="8.6 based on 20,000 user ratings"
+var ratingVotesRegexp = regexp.MustCompile (`based on ([\d,]+) user rating`)
+
+// leading rank of the title text e.g. "1. " in "1. Nayakan"
+var rankPrefixRegexp = regexp.MustCompile (`^\s*\d+\.\s*`)
+
+// charts whose title text is known to be prefixed with the rank, normalized by default
+var rankPrefixCharts = map[string]bool {
+    chart_url_Indian: true,
+    chart_url_Tamil:  true,
+    chart_url_Telugu: true,
+}
+
+// crawlState is what belongs to a crawl rather than to the package, as the crawls may
+// run at the same time, e.g. whether its titles are normalized. It is carried by the
+// context of the crawl.
+type crawlState struct {
+    normalizeTitles   bool    // strip the rank from the title, as per NormalizeTitle & the chart
+    storylineRequests int64   // storyline requests made so far, for MaxStorylineRequests
+}
+
+// key of the crawl state in the context
+type crawlStateKey struct{}
+
+// withCrawlState provides the context of the crawl carrying its state.
+func withCrawlState (ctx context.Context, s *crawlState) context.Context {
+    return context.WithValue (ctx, crawlStateKey{}, s)
+}
+
+// crawlStateOf provides the state of the crawl of the context, or a state of its own
+// for a page parsed outside of any crawl.
+func crawlStateOf (ctx context.Context) *crawlState {
+    if s, ok := ctx.Value(crawlStateKey{}).(*crawlState); ok {
+        return s
+    }
+    return &crawlState{}
+}
+
+
+// Structure to maintain the summary, duration, genre, the type of the title & the
+// credits (directors, stars & the principal cast) along with the storyline, the translated summary, the genre buckets, the
+// URL of the poster & the episodes of a TV series, if asked for.
+// The summary is the short one shown on the detail page while the storyline is the
+// longest of the summaries on the plot summary page.
+// facilitates easy conversion from structure to json by using the meta-fields
+type MovDetail struct {
+    Summary           string        `json:"summary"`
+    Storyline         string        `json:"storyline,omitempty"`
+    TranslatedSummary string        `json:"translated_summary,omitempty"`
+    Duration          string        `json:"duration"`
+    DurationMinutes   int           `json:"duration_minutes,omitempty"`
+    Genre             string        `json:"genre"`
+    CollapsedGenres   string        `json:"collapsed_genres,omitempty"`
+    TitleType         string        `json:"title_type"`
+    PosterURL         string        `json:"poster_url,omitempty"`
+    Directors         []string      `json:"directors,omitempty"`
+    Stars             []string      `json:"stars,omitempty"`
+    Cast              []string      `json:"cast,omitempty"`
+    Episodes          []EpisodeInfo `json:"episodes,omitempty"`
+
+    fetchErr          error         // of the detail page, if it could not be fetched
+}
+
+// Structure to maintain the fields of interest from the JSON-LD structured data
+// embedded in the detail page.
+type ldData struct {
+    Type            string  `json:"@type"`
+    Name            string  `json:"name"`
+    Duration        string  `json:"duration"`
+    DatePublished   string  `json:"datePublished"`
+    Image           ldImage `json:"image"`
+    AggregateRating struct {
+        RatingValue float64 `json:"ratingValue"`
+        RatingCount uint64  `json:"ratingCount"`
+    } `json:"aggregateRating"`
+    Director        ldPeople `json:"director"`
+    Actor           ldPeople `json:"actor"`
+}
+
+// Structure to maintain the IMDb title ID, title, release year, URL of the detail page crawled
+// as well as movie details like summary, duration & genre via embedding the MovDetail structure. The box-office figures
+// are only present for the box-office chart. The end year is only present for the TV series
+// rendered with a range of years, e.g. (2019–2023), the release year being its start.
+// facilitates easy conversion from structure to json by using the meta-fields
+// as the emebedded structure meta fields are also taken as is.
+type TitleData struct {
+    IMDbID      string `json:"imdb_id"`
+    Title       string `json:"title"`
+    ReleaseYear uint64 `json:"movie_release_year"`
+    EndYear     uint64 `json:"end_year,omitempty"`
+    DetailURL   string `json:"detail_url"`
+    MovDetail
+    *BoxOffice
+}
+
+// The overall chart data which specifies the TitleData, via embedding as well
+// as the rating & the number of votes that are obtained separately.
+// The rank is the position of the movie on the chart (or in the list), which it
+// keeps whatever the filters & the sorting.
+// The number of votes is 0 when it could not be obtained. The fields that could not
+// be parsed from the list (or the duration_minutes from the detail page, or details
+// for a detail page that could not be fetched) are named in Errors, e.g.
+// movie_release_year, for the consumers to tell the incomplete records from the zero
+// values. FieldErrors has the message of the failure of each of them, which is not
+// part of the JSON of the movie.
+// facilitates easy conversion from structure to json by using the meta-fields
+// as the emebedded structure meta fields are also taken as is.
+type ImdbChartData struct {
+    Rank        int          `json:"rank"`
+    TitleData
+    Rating      float64      `json:"imdb_rating"`
+    Votes       uint64       `json:"votes"`
+    Errors      []string     `json:"errors,omitempty"`
+    FieldErrors []FieldError `json:"-"`
+}
+
+// Structure to maintain a field of a movie that could not be obtained along with the
+// message of the failure, as warned about.
+// facilitates easy conversion from structure to json by using the meta-fields
+type FieldError struct {
+    Field   string `json:"field"`
+    Message string `json:"message"`
+}
+
+// failField reports the failure to obtain the field as a FAILURE warning, the operands
+// forming the message as for warn, & appends the field to errs along with it.
+func failField (errs *[]FieldError, field string, v ...interface{}) {
+    failFields (errs, []string {field}, v...)
+}
+
+// failFields is failField of several fields failing together.
+func failFields (errs *[]FieldError, fields []string, v ...interface{}) {
+
+    warn ("FAILURE", v...)
+    msg := strings.TrimSuffix (fmt.Sprintln (v...), "\n")
+    for _, field := range fields {
+        *errs = append (*errs, FieldError{field, msg})
+    }
+}
+
+// fieldNames provides the fields of the errors, in order, nil if none.
+func fieldNames (errs []FieldError) []string {
+
+    var fields []string
+    for _, e := range errs {
+        fields = append (fields, e.Field)
+    }
+    return fields
+}
+
+// Structure to maintain the outcome of a crawl, i.e. the movies along with the title
+// of the chart & the number of movies available on it, for the callers to tell whether
+// the result was clamped to the records available or cut down by the filters.
+// Failures is the number of the failures to fetch or parse the fields of the movies
+// (the FAILURE warnings), for the callers to tell whether the movies are complete. The crawls running at the same time share the count.
+type Chart struct {
+    Title          string
+    AvailableCount int
+    Movies         []ImdbChartData
+    Failures       int
+}
+
+// Structure to maintain the layout specific parsing of a page listing the movies.
+// Each type of page (chart, keyword search) has its own way of selecting the list
+// from the page, splitting it into the records of the movies & parsing a record.
+// The parsing of a record appends the fields it failed to parse to errs.
+type listLayout struct {
+    list      func (page string) string
+    rows      func (list string) []string
+    titleData func (ctx context.Context, movieRec string, t *TitleData, errs *[]FieldError, wg *sync.WaitGroup)
+    rating    func (ctx context.Context, movieRec string, rate *float64, votes *uint64, errs *[]FieldError, wg *sync.WaitGroup)
+}
+
+// layout of the chart pages, where each movie is a row of the table
+var chartLayout = listLayout{chartTable, chartRows, getTitleData, getRating}
+
+// Fetcher abstracts obtaining the body of the page at the given URL, so that the
+// pages can be served from somewhere other than the IMDb website when needed.
+// The fetch is to be abandoned once the context is done.
+type Fetcher interface {
+    Get (ctx context.Context, url string) (string, error)
+}
+
+// httpFetcher is the default Fetcher which obtains the page via http GET request
+// using its client. Each request carries one of its User-Agents picked at random,
+// or the one given by UserAgent if none are given.
+// The requests in flight are bounded by the capacity of slots, shared by all the
+// goroutines, unless it is nil.
+type httpFetcher struct {
+    client     *http.Client
+    userAgents []string
+    slots      chan struct{}
+}
+
+// the Fetcher used for every page requested by the program
+var fetcher Fetcher = httpFetcher{client: http.DefaultClient}
+
+// LoadUserAgents reads the User-Agents from the file, one per line. Blank lines &
+// lines starting with # are skipped.
+func LoadUserAgents (path string) ([]string, error) {
+
+    data, err := ioutil.ReadFile (path)
+    if err != nil {
+        return nil, err
+    }
+
+    userAgents := []string {}
+    for _, line := range strings.Split(string(data), "\n") {
+        line = strings.TrimSpace(line)
+        if line == "" || strings.HasPrefix (line, "#") {
+            continue
+        }
+        userAgents = append (userAgents, line)
+    }
+    if len (userAgents) == 0 {
+        return nil, fmt.Errorf ("No User-Agent in %s", path)
+    }
+    return userAgents, nil
+}
+
+// newRequest builds the GET request for the given URL with the headers common to
+// all the requests to IMDb. The request is cancelled along with the context.
+func (f httpFetcher) newRequest (ctx context.Context, url string) (*http.Request, error) {
+
+    req, err := http.NewRequestWithContext (ctx, http.MethodGet, url, nil)
+    if err != nil {
+        return nil, err
+    }
+
+    userAgent := opts.UserAgent
+    if len (f.userAgents) > 0 {
+        userAgent = f.userAgents[rand.Intn(len (f.userAgents))]
+    }
+    req.Header.Set("User-Agent", userAgent)
+    // the pages compressed, a fraction of their size; as the header is set here the
+    // transport leaves the body compressed, see readBody
+    req.Header.Set("Accept-Encoding", "gzip")
+    if opts.Cookie != "" {
+        req.Header.Set("Cookie", opts.Cookie)
+    }
+    return req, nil
+}
+
+// newHTTPClient provides the client for the requests to IMDb as per the options.
+// The transport starts off as a copy of the default one, so that the settings
+// not configured here (proxy from the environment etc.) stay the same.
+func newHTTPClient () *http.Client {
+
+    transport := http.DefaultTransport.(*http.Transport).Clone()
+    transport.IdleConnTimeout = opts.PoolIdleTimeout
+
+    // the proxy given, else that of the environment (HTTP_PROXY etc.) as by default
+    if proxy, _ := proxyURL (opts.Proxy); proxy != nil {
+        transport.Proxy = http.ProxyURL (proxy)
+    }
+
+    // the CAs of e.g. a TLS-inspecting proxy, or no verification at all for tests
+    if opts.RootCAs != nil || opts.InsecureTLS {
+        transport.TLSClientConfig = &tls.Config{RootCAs: opts.RootCAs, InsecureSkipVerify: opts.InsecureTLS}
+    }
+
+    // a hung response fails the fetch rather than blocking the crawl for ever
+    client := &http.Client{Transport: transport, Timeout: opts.Timeout}
+
+    // a negative value keeps the default policy of following up to 10 redirects
+    if opts.MaxRedirects >= 0 {
+        client.CheckRedirect = func (req *http.Request, via []*http.Request) error {
+            if len (via) > opts.MaxRedirects {
+                // stop here & let the redirect response itself be processed
+                return http.ErrUseLastResponse
+            }
+            return nil
+        }
+    }
+    return client
+}
+
+// LoadCACerts reads the PEM encoded CA certificates from the file, e.g. of a corporate
+// CA, trusted along with those of the system.
+func LoadCACerts (path string) (*x509.CertPool, error) {
+
+    data, err := ioutil.ReadFile (path)
+    if err != nil {
+        return nil, err
+    }
+
+    pool, err := x509.SystemCertPool()
+    if err != nil {
+        pool = x509.NewCertPool()
+    }
+    if !pool.AppendCertsFromPEM (data) {
+        return nil, fmt.Errorf ("No PEM certificate in %s", path)
+    }
+    return pool, nil
+}
+
+// proxyURL parses the URL of the proxy to route the requests through, an http(s) or
+// socks5 one. Nil if none is given.
+func proxyURL (proxy string) (*url.URL, error) {
+
+    if proxy == "" {
+        return nil, nil
+    }
+    u, err := url.Parse (proxy)
+    if err != nil {
+        return nil, err
+    }
+    if u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "socks5" {
+        return nil, fmt.Errorf ("%q is not an http(s) or socks5 URL", proxy)
+    }
+    if u.Host == "" {
+        return nil, fmt.Errorf ("%q has no host", proxy)
+    }
+    return u, nil
+}
+
+// statusError is the error for a response from IMDb which is not OK.
+type statusError struct {
+    code     int
+    location string
+}
+
+func (e statusError) Error () string {
+    // a redirect that was not followed, report where IMDb wanted to send us
+    if e.location != "" {
+        return fmt.Sprintf ("Cannot process response. Response Code: %d, redirected to %s", e.code, e.location)
+    }
+    return fmt.Sprintf ("Cannot process response. Response Code: %d", e.code)
+}
+
+// Get obtains the response body of the given URL from the IMDb website.
+func (f httpFetcher) Get (ctx context.Context, url string) (string, error) {
+
+    // wait for a slot, a request being in flight till its body is read
+    if f.slots != nil {
+        select {
+        case f.slots<- struct{}{}:
+            defer func (){ <-f.slots }()
+        case <-ctx.Done():
+            return "", ctx.Err()
+        }
+    }
+
+    // the chart, details, summaries etc. all spaced out alike, keeping the overall
+    // rate under the ceiling
+    requestDelayWait (ctx)
+    if err := ctx.Err(); err != nil {
+        return "", err
+    }
+
+    start := time.Now()
+    requestCount.Add(1)
+
+    req, err := f.newRequest (ctx, url)
+    if err != nil{
+        fetchErrors.Add(1)
+        observer.Fetched (time.Since(start), fetchErr_Request)
+        return "", fmt.Errorf ("Failed to build GET request: %v", err)
+    }
+    resp, err := f.client.Do (req)
+    if err != nil{
+        fetchErrors.Add(1)
+        observer.Fetched (time.Since(start), fetchErr_Request)
+        return "", fmt.Errorf ("Failed to establish GET request: %v", err)
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusOK {
+        fetchErrors.Add(1)
+        observer.Fetched (time.Since(start), fetchErr_Status)
+        return "", statusError{resp.StatusCode, resp.Header.Get("Location")}
+    }
+    body, err := readBody (resp)
+    if err != nil{
+        fetchErrors.Add(1)
+        observer.Fetched (time.Since(start), fetchErr_Body)
+        return "", fmt.Errorf ("Failed to obtain response body: %v", err)
+    }
+
+    // a consent/age-gate page served instead of the one asked for, with 200 OK
+    if kind := detectInterstitial (string(body)); kind != "" {
+        fetchErrors.Add(1)
+        observer.Fetched (time.Since(start), fetchErr_Gate)
+        return "", interstitialError{kind, url}
+    }
+    observer.Fetched (time.Since(start), "")
+    return string(body), nil
+}
+
+// readBody reads the body of the response, decompressing it if gzip encoded. The
+// transport decompresses it by itself only when it asked for gzip on its own, not
+// when the request has the Accept-Encoding header set as ours do.
+func readBody (resp *http.Response) ([]byte, error) {
+
+    if resp.Uncompressed || !strings.EqualFold (resp.Header.Get("Content-Encoding"), "gzip") {
+        return ioutil.ReadAll (resp.Body)
+    }
+    zr, err := gzip.NewReader (resp.Body)
+    if err != nil {
+        return nil, err
+    }
+    defer zr.Close()
+    return ioutil.ReadAll (zr)
+}
+
+// fetchMoreInfo fetches & parses the detail page at the given URL, spaced out from
+// the other requests as per RequestDelay, unless cached. The details are left empty
+// if the page could not be fetched.
+func fetchMoreInfo (ctx context.Context, cUrl string) MovDetail {
+
+    respBody, err := detailPage (ctx, cUrl)
+    if errors.As (err, &robotsError{}) {
+        // not a failure, the details are left empty on purpose
+        info ("Skipped the details.", err)
+        return MovDetail{}
+    }
+    if err != nil{
+        warn ("FAILURE", "Could not fetch more info.", err)
+        return MovDetail{fetchErr: err}
+    }
+    return parseMoreInfo (ctx, cUrl, respBody)
+}
+
+// detailPage provides the detail page at the given URL from the cache, else fetches
+// it via detailGet & caches it.
+func detailPage (ctx context.Context, cUrl string) (string, error) {
+
+    if respBody, ok := cacheLoad (cUrl); ok {
+        info ("Loaded the details of", cUrl, "from the cache")
+        return respBody, nil
+    }
+
+    respBody, err := detailGet (ctx, cUrl)
+    if err != nil {
+        return "", err
+    }
+    info ("Fetched the details of", cUrl)
+    cacheStore (cUrl, respBody)
+    return respBody, nil
+}
+
+// setDetails puts the details of the detail page in the title data, keeping the
+// poster of the list if the page has none, & appends the fields of the details that
+// could not be parsed to errs, or details if the page could not be fetched at all.
+func setDetails (t *TitleData, d MovDetail, errs *[]FieldError) {
+
+    if d.PosterURL == "" {
+        d.PosterURL = t.PosterURL
+    }
+    t.MovDetail = d
+    if d.fetchErr != nil {
+        *errs = append (*errs, FieldError{field_Details, fmt.Sprint ("Could not fetch more info. ", d.fetchErr)})
+    }
+    checkDuration (d, errs)
+}
+
+// slots of the detail page & storyline requests in flight, bounded by DetailConcurrency
+// apart from the bound of all the requests, no bound if nil
+var detailSlots chan struct{}
+
+// detailGet obtains the detail page (or the plot summary page) at the given URL via
+// the fetcher, within a slot of DetailConcurrency. The slot is held only for the
+// fetch, not for the parsing which may fetch further pages.
+func detailGet (ctx context.Context, pageUrl string) (string, error) {
+
+    if detailSlots != nil {
+        select {
+        case detailSlots<- struct{}{}:
+            defer func (){ <-detailSlots }()
+        case <-ctx.Done():
+            return "", ctx.Err()
+        }
+    }
+    return fetcher.Get (ctx, pageUrl)
+}
+
+// start of the latest request, for spacing the requests by RequestDelay
+var (
+    requestDelayMu sync.Mutex
+    lastRequest    time.Time
+)
+
+// requestDelayWait waits so that the requests start at least RequestDelay apart. As
+// the pages are fetched concurrently, a sleep of its own by each would not space
+// them out. The wait is cut short once the context is done.
+func requestDelayWait (ctx context.Context) {
+
+    if opts.RequestDelay <= 0 {
+        return
+    }
+
+    requestDelayMu.Lock()
+    defer requestDelayMu.Unlock()
+
+    if wait := opts.RequestDelay - time.Since(lastRequest); wait > 0 {
+        select {
+        case <-time.After (wait):
+        case <-ctx.Done():
+        }
+    }
+    lastRequest = time.Now()
+}
+
+// storylineAllowed counts one more storyline request of the crawl & tells whether it
+// is within the cap given by MaxStorylineRequests, if any.
+func storylineAllowed (ctx context.Context) bool {
+    if opts.MaxStorylineRequests <= 0 {
+        return true
+    }
+    return atomic.AddInt64(&crawlStateOf(ctx).storylineRequests, 1) <= int64(opts.MaxStorylineRequests)
+}
+
+// plotSummaryURL provides the plot summary page of the movie at the given URL, i.e.
+// that of its title ID, as the URL may have a query (e.g. ?ref_=) which the path is
+// not to be appended to.
+func plotSummaryURL (cUrl string) string {
+
+    if id := titleID (cUrl); id != "" {
+        return strings.TrimSuffix(idURL (id), "/") + plotSummary_path
+    }
+    if i := strings.IndexAny(cUrl, "?#"); i != -1 {
+        cUrl = cUrl[ : i]
+    }
+    return strings.TrimSuffix(cUrl, "/") + plotSummary_path
+}
+
+// parseMoreInfo parses the duration, genre & summary from the detail page of the
+// movie at the given URL, crawling the further pages (storyline, episodes) as asked
+// for.
+func parseMoreInfo (ctx context.Context, cUrl string, respBody string) MovDetail {
+
+    // duration, unless overridden by the user supplied regexp
+    duration, overridden := overrideField (field_Duration, respBody)
+    durEndIdx := strings.Index(respBody, `</time>`)
+    if !overridden && durEndIdx != -1 {
+        if durStrtIdx := strings.LastIndex(respBody[ : durEndIdx], `>`); durStrtIdx != -1 {
+            durStrtIdx++
+            debug ("Duration of", cUrl, "at", durStrtIdx, durEndIdx)
+            duration = strings.TrimSpace(respBody[durStrtIdx : durEndIdx])
+        }
+    }
+    duration = html.UnescapeString(duration)
+
+    // summary, unless overridden by the user supplied regexp
+    summary, overridden := overrideField (field_Summary, respBody)
+    if !overridden {
+        summary = summaryText (respBody)
+        if summary == "" {
+            debug ("No summary on", cUrl)
+        }
+    }
+    // e.g. &amp; & &#39; as the characters they stand for, also for the translation
+    summary = truncateText (html.UnescapeString(summary), opts.MaxSummaryLength)
+
+    // storyline i.e. the long summary, from the plot summary page
+    // an extra request, hence only when asked for
+    // the goroutine extracts it while the rest is parsed & hands it over via its own
+    // channel, buffered so that it never waits for the receive
+    storylineChan := make (chan string, 1)
+    if opts.Storyline && storylineAllowed (ctx) {
+        go func (){
+            respBody, err := detailGet (ctx, plotSummaryURL (cUrl))
+            if err != nil{
+                warn ("FAILURE", "Could not fetch the storyline.", err)
+                storylineChan<- ""
+                return
+            }
+            storylineChan<- truncateText (html.UnescapeString(longestPlotSummary (respBody)), opts.MaxSummaryLength)
+        }()
+    } else {
+        storylineChan<- ""
+    }
+
+    // summary in the language asked for, by the translation endpoint
+    translatedChan := make (chan string, 1)
+    if opts.TranslateTo != "" && summary != "" {
+        go func (){
+            translated, err := translate (ctx, opts.TranslateURL, summary, opts.TranslateTo)
+            if err != nil {
+                warn ("FAILURE", "Could not translate the summary.", err)
+            }
+            translatedChan<- translated
+        }()
+    } else {
+        translatedChan<- ""
+    }
+
+    // genre, unless overridden by the user supplied regexp, where the genres are
+    // comma separated, else the links of the genres
+    genreLst := []string {}
+    if genres, overridden := overrideField (field_Genre, respBody); overridden {
+        for _, genre := range strings.Split(genres, ",") {
+            if genre = stripTags (genre); genre != "" {
+                genreLst = append (genreLst, genre)
+            }
+        }
+    } else {
+        genreLst = genreLinks (respBody)
+    }
+
+    // genre buckets
+    collapsedGenres := ""
+    if opts.CollapseGenres {
+        collapsedGenres = collapseGenres (genreLst)
+    }
+
+    // title type i.e. Movie, TVSeries, TVEpisode etc.
+    ld := extractJSONLD (respBody)
+
+    // the runtime from the structured data (ISO 8601 e.g. PT2H6M) when the page
+    // does not render it, which is not fit for display as is
+    if duration == "" {
+        duration = ld.Duration
+    }
+    durationMins, ok := durationMinutes (duration)
+    if !ok && duration != "" {
+        warn ("FAILURE", "Could not parse the duration", strconv.Quote(duration), "of", cUrl)
+    }
+    if opts.PrettyDuration || strings.HasPrefix (duration, "PT") {
+        duration = prettyDuration (duration)
+    }
+
+    // poster, in the higher resolution of the detail page
+    poster := ""
+    if opts.IncludePosterURL {
+        poster = detailPoster (respBody, ld)
+    }
+
+    // directors & stars from the structured data, else from the credit summary
+    directors, stars := []string(ld.Director), []string(ld.Actor)
+    if len (directors) == 0 && len (stars) == 0 {
+        directors, stars = creditSummary (respBody)
+    }
+    cast := castList (respBody)
+
+    // episodes of the TV series
+    var episodes []EpisodeInfo
+    if opts.TVEpisodes && ld.Type == "TVSeries" {
+        episodes = crawlEpisodes (ctx, cUrl)
+    }
+
+    // the storyline & the translation are received only now, from their own channels
+    return MovDetail{
+        Summary:           summary,
+        Storyline:         <-storylineChan,
+        TranslatedSummary: <-translatedChan,
+        Duration:          duration,
+        DurationMinutes:   durationMins,
+        Genre:             strings.Join(genreLst, ", "),
+        CollapsedGenres:   collapsedGenres,
+        TitleType:         ld.Type,
+        PosterURL:         poster,
+        Directors:         directors,
+        Stars:             stars,
+        Cast:              cast,
+        Episodes:          episodes,
+    }
+}
+
+// summaryText extracts the summary from the detail page, i.e. the text of the summary
+// div, or empty if the page has none (e.g. a title yet to be released) or it is not
+// closed.
+func summaryText (respBody string) string {
+
+    summaryDivAttr := `<div class="`+summary_class+`">`
+    summaryStrtIdx := strings.Index(respBody, summaryDivAttr)
+    if summaryStrtIdx == -1 {
+        return ""
+    }
+    summaryStrtIdx += len (summaryDivAttr)
+    summaryEndIdx := strings.Index(respBody[summaryStrtIdx : ], `</div>`)
+    if summaryEndIdx == -1 {
+        return ""
+    }
+    summaryEndIdx += summaryStrtIdx
+    summary := strings.TrimSpace(respBody[summaryStrtIdx : summaryEndIdx])
+
+    // the summary may not be complete & be followed by a link to the full summary
+    // which is not part of the summary itself
+    if newLnk := strings.Index (summary, `<a href="`); newLnk != -1 {
+        summary = strings.TrimSpace(summary[ : newLnk])
+    }
+    return summary
+}
+
+// extractJSONLD obtains the JSON-LD structured data embedded in the detail page.
+// An empty structure is provided if the page does not have it.
+func extractJSONLD (respBody string) ldData {
+
+    var ld ldData
+
+    ldStrtIdx := strings.Index(respBody, jsonLD_script)
+    if ldStrtIdx == -1 {
+        return ld
+    }
+    ldStrtIdx += len (jsonLD_script)
+    ldEndIdx := strings.Index(respBody[ldStrtIdx : ], `</script>`)
+    if ldEndIdx == -1 {
+        return ld
+    }
+    ldEndIdx += ldStrtIdx
+
+    if err := json.Unmarshal ([]byte(respBody[ldStrtIdx : ldEndIdx]), &ld); err != nil {
+        warn ("FAILURE", "Could not parse the structured data.", err)
+    }
+    return ld
+}
+
+// longestPlotSummary extracts the longest of the summaries listed on the plot summary
+// page, or the first paragraph of the page if the summaries are not found as such.
+func longestPlotSummary (respBody string) string {
+
+    longest := ""
+    for _, m := range plotSummaryRegexp.FindAllStringSubmatch(respBody, -1) {
+        if plot := strings.TrimSpace(m[1]); len (plot) > len (longest) {
+            longest = plot
+        }
+    }
+    if longest != "" {
+        return longest
+    }
+
+    pStrtIdx := strings.Index(respBody, `<p>`)
+    if pStrtIdx == -1 {
+        return ""
+    }
+    pStrtIdx += len (`<p>`)
+    pEndIdx := strings.Index(respBody[pStrtIdx : ], `</p>`)
+    if pEndIdx == -1 {
+        return ""
+    }
+    return strings.TrimSpace(respBody[pStrtIdx : pStrtIdx + pEndIdx])
+}
+
+// genreLinks provides the genres of the detail page, i.e. the text of the links to
+// the genres, in the order of the page. The movie can be of multiple genres, each
+// having a link, which may be repeated further down the page (e.g. the storyline),
+// hence only the first link of each genre is taken.
+func genreLinks (respBody string) []string {
+
+    // create a slice of genres and later join them
+    // better than creating multiple strings by concatenation
+    genreLst := []string {}
+    seen := map[string]bool {}
+    for _, m := range genreLinkRegexp.FindAllStringSubmatch(respBody, -1) {
+        genre := stripTags (m[1])
+        if genre == "" || seen[genre] {
+            continue
+        }
+        seen[genre] = true
+        genreLst = append (genreLst, genre)
+    }
+    return genreLst
+}
+
+// stripTags provides the text content of the HTML fragment, i.e. without the nested
+// elements like <i> or <span> but with their text, the entities (&amp; etc.) being
+// decoded.
+func stripTags (fragment string) string {
+    return strings.TrimSpace(html.UnescapeString(htmlTagRegexp.ReplaceAllString(fragment, "")))
+}
+
+// truncateText cuts the text down to at most max characters, at the end of a word if
+// there is one, marking the cut by an ellipsis. The text is kept as is if it is not
+// longer or if max is 0.
+func truncateText (text string, max int) string {
+
+    runes := []rune(text)
+    if max <= 0 || len (runes) <= max {
+        return text
+    }
+    cut := string(runes[ : max])
+    if space := strings.LastIndexAny (cut, " \t\n"); space > 0 && !unicode.IsSpace (runes[max]) {
+        cut = cut[ : space]
+    }
+    return strings.TrimRightFunc(cut, unicode.IsSpace) + "…"
+}
+
+// cleanTitle strips the leading rank & the surrounding whitespace from the title, if
+// the titles of the crawl are to be normalized.
+func cleanTitle (ctx context.Context, title string) string {
+    if !crawlStateOf(ctx).normalizeTitles {
+        return title
+    }
+    return strings.TrimSpace(rankPrefixRegexp.ReplaceAllString(title, ""))
+}
+
+// getTitleData is triggered as a goroutine and it fetches & parses the data from
+// the IMDb row of the table. The row is parsed by parseTitleRow, its title being
+// normalized as per the crawl, & then the summary, genre & duration are fetched from
+// the detail page.
+func getTitleData (ctx context.Context, movieRec string, t *TitleData, errs *[]FieldError, wg *sync.WaitGroup) {
+
+    defer wg.Done()
+
+    parseTitleRow (movieRec, t, errs)
+    t.Title = cleanTitle (ctx, t.Title)
+
+    // fetch summary, duration & genre once the row is parsed
+    // not needed for the lite output, nor possible without the link
+    if crawlInline() && t.DetailURL != "" {
+        setDetails (t, fetchMoreInfo (ctx, t.DetailURL), errs)
+    }
+}
+
+// parseTitleRow parses the data present in the IMDb row of the table, i.e. the link
+// to more info, the movie title & release date, without any request, appending the
+// fields it failed to parse to errs.
+func parseTitleRow (movieRec string, t *TitleData, errs *[]FieldError) {
+
+    // title data
+    // contains title, release year, and link to summary, duration & genre
+    tdtitleAttr := `<td class="`+td_titleClass+`">`
+    titleStrtIdx := strings.Index(movieRec, tdtitleAttr)
+    if titleStrtIdx == -1 {
+        failField (errs, field_Title, "Could not find the title column")
+        return
+    }
+    titleStrtIdx += len (tdtitleAttr)
+    titleEndIdx := strings.Index(movieRec[titleStrtIdx : ], `</td>`)
+    if titleEndIdx == -1 {
+        titleEndIdx = len (movieRec)
+    } else {
+        titleEndIdx += titleStrtIdx
+    }
+    debug ("Title column of the row at", titleStrtIdx, titleEndIdx)
+
+    // link to more info, a column without one having no title either
+    moreInfoAttr := `<a href="`
+    urlStrtIdx := strings.Index(movieRec[titleStrtIdx : titleEndIdx], moreInfoAttr)
+    if urlStrtIdx == -1 {
+        failField (errs, field_Title, "Could not find the link in the title column")
+        return
+    }
+    urlStrtIdx += titleStrtIdx + len (moreInfoAttr)
+    urlEndIdx := strings.Index(movieRec[urlStrtIdx : titleEndIdx], `"`)
+    if urlEndIdx == -1 {
+        failField (errs, field_Title, "Could not find the link in the title column")
+        return
+    }
+    urlEndIdx += urlStrtIdx
+    // without the query (e.g. ?ref_=chttp_t_1), as for the other layouts
+    moreInfoURL := imdb_url_Main + movieRec[urlStrtIdx : urlEndIdx]
+    if queryIdx := strings.Index(moreInfoURL, "?"); queryIdx != -1 {
+        moreInfoURL = moreInfoURL[ : queryIdx]
+    }
+    t.DetailURL = moreInfoURL
+    t.IMDbID = titleID (moreInfoURL)
+
+    // only title, i.e. the text of the link up to its own </a>, as the link may be
+    // followed by others in the column e.g. of an award badge
+    lnkTextStrtIdx := urlEndIdx + strings.Index(movieRec[urlEndIdx : titleEndIdx], `>`) + 1
+    lnkTextEndIdx := strings.Index(movieRec[lnkTextStrtIdx : titleEndIdx], `</a>`)
+    if lnkTextEndIdx == -1 {
+        failField (errs, field_Title, "Could not find the title in the title column")
+        return
+    }
+    title := stripTags (movieRec[lnkTextStrtIdx : lnkTextStrtIdx + lnkTextEndIdx])
+    t.Title = title
+
+    // release date, up to the </span> of its own for the same reason
+    releaseDateAttr := `<span class="`+releaseYear_class+`">`
+    releaseYear := ""
+    if yearStrtIdx := strings.Index(movieRec[titleStrtIdx : titleEndIdx], releaseDateAttr); yearStrtIdx != -1 {
+        yearStrtIdx += titleStrtIdx + len (releaseDateAttr)
+        if yearEndIdx := strings.Index(movieRec[yearStrtIdx : titleEndIdx], `</span>`); yearEndIdx != -1 {
+            // within the parentheses
+            releaseYear = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(movieRec[yearStrtIdx : yearStrtIdx + yearEndIdx]), "("), ")")
+        }
+    }
+    year, endYear, err := parseYearRange (releaseYear)
+    if err != nil {
+        failField (errs, field_ReleaseYear, "Could not obtain release year for", title, err)
+    }
+    t.ReleaseYear, t.EndYear = year, endYear
+
+    // poster thumbnail, until the one of the detail page
+    if opts.IncludePosterURL {
+        t.PosterURL = rowPoster (movieRec)
+    }
+}
+
+// getRating handles the extraction of rating & the number of votes from the specific
+// row for that movie.
+// As this is triggered as a goroutine, it processes the rating and populates the
+// correct fields supplied concurrently.
+func getRating (ctx context.Context, movieRec string, rate *float64, votes *uint64, errs *[]FieldError, wg *sync.WaitGroup) {
+
+    defer wg.Done()
+
+    // rating, the lists like the watchlists have no rating column
+    tdRatingAttr := `<td class="`+td_ratingClass+`">`
+    ratingStrtIdx := strings.Index(movieRec, tdRatingAttr)
+    if ratingStrtIdx == -1 {
+        failFields (errs, []string {field_Rating, field_Votes}, "Could not find the rating column")
+        return
+    }
+    ratingStrtIdx += len (tdRatingAttr)
+    ratingEndIdx := strings.Index(movieRec[ratingStrtIdx : ], `</td>`)
+    if ratingEndIdx == -1 {
+        ratingEndIdx = len (movieRec)
+    } else {
+        ratingEndIdx += ratingStrtIdx
+    }
+    debug ("Rating column of the row at", ratingStrtIdx, ratingEndIdx)
+
+    // the rating is the text of the <strong> of the column
+    rateStrtIdx := strings.Index(movieRec[ratingStrtIdx : ratingEndIdx], `>`)
+    rateEndIdx := strings.LastIndex(movieRec[ratingStrtIdx : ratingEndIdx], `</strong>`)
+    if rateStrtIdx == -1 || rateEndIdx <= rateStrtIdx {
+        failField (errs, field_Rating, "Could not find the rating in the rating column")
+    } else {
+        rating := movieRec[ratingStrtIdx + rateStrtIdx + 1 : ratingStrtIdx + rateEndIdx]
+        imdbRate, err := parseRating (rating)
+        if err != nil {
+            failField (errs, field_Rating, "Could not obtain rating")
+        }
+        *rate = imdbRate
+    }
+
+    // number of votes as mentioned in the title of the rating
+    voteMatch := ratingVotesRegexp.FindStringSubmatch(movieRec[ratingStrtIdx : ratingEndIdx])
+    if voteMatch == nil {
+        failField (errs, field_Votes, "Could not obtain number of votes")
+        return
+    }
+    voteCount, err := strconv.ParseUint(strings.ReplaceAll(voteMatch[1], ",", ""), 10, 64)
+    if err != nil {
+        failField (errs, field_Votes, "Could not obtain number of votes")
+    }
+    *votes = voteCount
+}
+
+// parseRating parses the rating as rendered in the chart, which for some locales is
+// padded with newlines or non-breaking spaces (also as &nbsp;) & has a decimal comma
+// e.g. "8,6".
+func parseRating (rating string) (float64, error) {
+
+    rating = strings.NewReplacer ("&nbsp;", "", "&#160;", "", "\u00a0", "", ",", ".").Replace (rating)
+    return strconv.ParseFloat(strings.TrimSpace(rating), 64)
+}
+
+// chartTable extracts only the table containing the movie list from the chart page.
+// Empty if the page has no table.
+func chartTable (page string) string {
+
+    tableStrtIdx := strings.Index(page, "<table")
+    tableEndIdx := strings.Index(page, "</table>")
+    if tableStrtIdx == -1 || tableEndIdx < tableStrtIdx {
+        return ""
+    }
+    return page[tableStrtIdx : tableEndIdx + len ("</table>")]
+}
+
+// chartRows splits the chart table into the rows of the movies. The first part of the
+// split is what precedes the first row (the <table> & <thead> tags), not a row, & the
+// header row(s) have the headings of the columns (<th>) rather than a movie, hence
+// both are skipped. The header row is told by its headings rather than by its place,
+// so that a table without one loses none of its movies.
+func chartRows (table string) []string {
+
+    rows := tableRowRegexp.Split(table, -1)
+    if len (rows) < 2 {
+        return nil
+    }
+    recSlc := []string {}
+    for _, row := range rows[1 : ] {
+        if tableHeadingRegexp.MatchString (row) {
+            continue
+        }
+        recSlc = append (recSlc, row)
+    }
+    return recSlc
+}
+
+// parseTableData is the master that is responsible for trigerring the proper
+// goroutine and synchronizing them, all while parsing the given data as per the
+// IMDb website.
+// The rows, split from the list (of all its pages) beforehand, are processed one per
+// movie as per the layout of the page. Then end result is
+// the requested number of records or the maximum number of records currently
+// available for that category, starting from the rank given by RankFrom and not
+// going beyond the rank given by RankTo.
+// When all the movies are processed, they are sent back as the Chart along with
+// the chart title & the number of records available. If movieChan is given, each
+// movie passing the filters is sent over it as well, as soon as it is processed,
+// the channel being closed once all the movies are.
+func parseTableData(ctx context.Context, recSlc []string, layout listLayout, chartTitle string, item_count int, parserChan chan<- *Chart, movieChan chan<- ImdbChartData) {
+
+    var wg sync.WaitGroup
+
+    crawlCount.Add(1)
+    crawlStart := time.Now()
+    failuresBefore := atomic.LoadInt64(&failureCount)
+
+    // restrict to the requested window of ranks, the end of the window is
+    // trimmed first as both the ends are in terms of the chart rank
+    if opts.RankTo > 0 && opts.RankTo < len (recSlc) {
+        recSlc = recSlc[ : opts.RankTo]
+    }
+    if opts.RankFrom > len (recSlc) {
+        recSlc = recSlc[len (recSlc) : ]
+    } else {
+        recSlc = recSlc[opts.RankFrom - 1 : ]
+    }
+
+    // a count of 0 is for all the records available
+    all_records := item_count == 0
+    if (item_count > len (recSlc)){
+        warn ("ALARM", "Only", len (recSlc), "records available")
+	item_count = len (recSlc)
+    }
+    if all_records {
+        item_count = len (recSlc)
+    }
+
+    // the count is of the movies rated at least MinRating, if given, i.e. the first
+    // ones passing it; hence all the rows of the window are scanned for them
+    scan_count := item_count
+    if opts.MinRating > 0 {
+        scan_count = len (recSlc)
+    }
+
+    // exactly one entry per row to be processed, so that no row goes beyond the slice
+    imdbChartTable := make([]ImdbChartData, scan_count)
+
+    // the fields failed by each of the goroutines of a movie are kept apart, being
+    // appended concurrently
+    titleErrs := make([][]FieldError, scan_count)
+    ratingErrs := make([][]FieldError, scan_count)
+
+    // a row of an unexpected layout is left incomplete rather than crashing the run
+    for i, mov := range recSlc[ : scan_count] {
+        wg.Add(1)
+        go func (i int, mov string) {
+            defer wg.Done()
+
+            var rowWg sync.WaitGroup
+            rowWg.Add(2)
+            go func (){
+                defer guardRow (&titleErrs[i], field_Title)
+                layout.titleData (ctx, mov, &imdbChartTable[i].TitleData, &titleErrs[i], &rowWg)
+            }()
+            go func (){
+                defer guardRow (&ratingErrs[i], field_Rating)
+                layout.rating (ctx, mov, &imdbChartTable[i].Rating, &imdbChartTable[i].Votes, &ratingErrs[i], &rowWg)
+            }()
+            rowWg.Wait()
+
+            imdbChartTable[i].Rank = opts.RankFrom + i
+            imdbChartTable[i].FieldErrors = append (titleErrs[i], ratingErrs[i]...)
+            imdbChartTable[i].Errors = fieldNames (imdbChartTable[i].FieldErrors)
+
+            // the movie is complete, hand it over right away if streamed
+            if movieChan != nil && passesFilters (imdbChartTable[i]) {
+                select {
+                case movieChan<- imdbChartTable[i]:
+                case <-ctx.Done():
+                }
+            }
+        }(i, mov)
+    }
+
+    // wait for the goroutines to complete populating the fields
+    wg.Wait()
+    moviesFetched.Add(int64(scan_count))
+    if movieChan != nil {
+        close (movieChan)
+    }
+
+    // the filters on the chart data come first, so that the details can be crawled
+    // only for the movies passing them
+    if opts.MinRating > 0 {
+        imdbChartTable = filterMinRating (imdbChartTable, opts.MinRating)
+    }
+
+    // drop the less popular movies
+    if opts.MinVotes > 0 {
+        imdbChartTable = filterMinVotes (imdbChartTable, opts.MinVotes, opts.KeepUnknownVotes)
+    }
+
+    // only the movies that newly entered the chart
+    if opts.Baseline != nil {
+        imdbChartTable = filterNew (imdbChartTable, opts.Baseline)
+    }
+
+    // with MinRating, the count is of the first movies passing all the filters, those
+    // of the title types only once the details are crawled
+    if opts.MinRating > 0 && len (opts.TitleTypes) == 0 {
+        imdbChartTable = firstPassing (imdbChartTable, item_count, all_records)
+    }
+
+    // the details deferred till the chart filters are done
+    if deferDetails() && needDetails() {
+        crawlDetails (ctx, imdbChartTable)
+    }
+    observer.Crawled (time.Since(crawlStart), scan_count)
+
+    // keep only the requested types of titles
+    if len (opts.TitleTypes) > 0 {
+        imdbChartTable = filterTitleType (imdbChartTable, opts.TitleTypes)
+        if opts.MinRating > 0 {
+            imdbChartTable = firstPassing (imdbChartTable, item_count, all_records)
+        }
+    }
+
+    // the movies are in the chart order unless sorted otherwise
+    if opts.SortBy != "" {
+        sortMovies (imdbChartTable, opts.SortBy, opts.SortStable)
+    }
+
+    // send the movies back to the caller, unless it gave up on the crawl
+    select {
+    case parserChan<- &Chart{chartTitle, len (recSlc), imdbChartTable, int(atomic.LoadInt64(&failureCount) - failuresBefore)}:
+    case <-ctx.Done():
+    }
+}
+
+// firstPassing provides the first movies of the count out of those passing the
+// filters, warning if fewer of them passed unless all the records are asked for.
+func firstPassing (imdbChartTable []ImdbChartData, item_count int, all_records bool) []ImdbChartData {
+
+    if len (imdbChartTable) < item_count && !all_records {
+        warn ("ALARM", "Only", len (imdbChartTable), "records rated at least", opts.MinRating, "& passing the filters")
+    } else if len (imdbChartTable) > item_count {
+        imdbChartTable = imdbChartTable[ : item_count]
+    }
+    return imdbChartTable
+}
+
+// passesFilters tells whether the movie passes the filters that apply to each movie
+// on its own, i.e. all but MinRating which is of the first movies passing it.
+func passesFilters (mov ImdbChartData) bool {
+
+    movies := []ImdbChartData{mov}
+    if opts.MinVotes > 0 {
+        movies = filterMinVotes (movies, opts.MinVotes, opts.KeepUnknownVotes)
+    }
+    if opts.Baseline != nil {
+        movies = filterNew (movies, opts.Baseline)
+    }
+    if len (opts.TitleTypes) > 0 {
+        movies = filterTitleType (movies, opts.TitleTypes)
+    }
+    return len (movies) == 1
+}
+
+// guardRow recovers from a panic while parsing a row, which is due to a layout other
+// than the expected one, noting the field as failed.
+func guardRow (errs *[]FieldError, field string) {
+    if r := recover(); r != nil {
+        failField (errs, field, "Could not parse the row.", r)
+    }
+}
+
+// movie fields to sort by, each telling whether a movie is to be placed before the
+// other in the ascending order
+var sortKeys = map[string]func (a, b ImdbChartData) bool {
+    "rank":   func (a, b ImdbChartData) bool { return a.Rank < b.Rank },
+    "rating": func (a, b ImdbChartData) bool { return a.Rating < b.Rating },
+    "votes":  func (a, b ImdbChartData) bool { return a.Votes < b.Votes },
+    "year":   func (a, b ImdbChartData) bool { return a.ReleaseYear < b.ReleaseYear },
+    "title":  func (a, b ImdbChartData) bool { return a.Title < b.Title },
+}
+
+// parseSortKey provides the field of the sort key & whether the order is descending,
+// as told by a "-" prefix or a "-desc" suffix e.g. -rating or rating-desc.
+func parseSortKey (key string) (string, bool) {
+
+    if strings.HasPrefix (key, "-") {
+        return key[1 : ], true
+    }
+    if strings.HasSuffix (key, "-desc") {
+        return strings.TrimSuffix (key, "-desc"), true
+    }
+    return key, false
+}
+
+// sortMovies sorts the movies in place by the given key, in the descending order if
+// the key is prefixed with "-". With stable, the movies having equal keys stay in
+// the chart order, so the output is the same for the same input.
+func sortMovies (imdbChartTable []ImdbChartData, key string, stable bool) {
+
+    field, desc := parseSortKey (key)
+    less := sortKeys[field]
+
+    byKey := func (i, j int) bool {
+        if desc {
+            return less (imdbChartTable[j], imdbChartTable[i])
+        }
+        return less (imdbChartTable[i], imdbChartTable[j])
+    }
+    if stable {
+        sort.SliceStable (imdbChartTable, byKey)
+    } else {
+        sort.Slice (imdbChartTable, byKey)
+    }
+}
+
+// filterMinRating provides only the movies rated at least the given rating. The
+// movies whose rating is unknown are dropped.
+func filterMinRating (imdbChartTable []ImdbChartData, min float64) []ImdbChartData {
+
+    filtered := []ImdbChartData {}
+
+    for _, mov := range imdbChartTable {
+        if mov.Rating >= min {
+            filtered = append (filtered, mov)
+        }
+    }
+    return filtered
+}
+
+// filterMinVotes provides only the movies having at least the given number of votes.
+// The movies whose number of votes is unknown are kept or dropped as specified.
+func filterMinVotes (imdbChartTable []ImdbChartData, min uint64, keepUnknown bool) []ImdbChartData {
+
+    filtered := []ImdbChartData {}
+
+    for _, mov := range imdbChartTable {
+        if mov.Votes >= min || (mov.Votes == 0 && keepUnknown) {
+            filtered = append (filtered, mov)
+        }
+    }
+    return filtered
+}
+
+// filterTitleType provides only the movies whose title type is one of the given
+// types. The comparison is case insensitive.
+func filterTitleType (imdbChartTable []ImdbChartData, types []string) []ImdbChartData {
+
+    filtered := []ImdbChartData {}
+
+    for _, mov := range imdbChartTable {
+        for _, t := range types {
+            if strings.EqualFold(mov.TitleType, strings.TrimSpace(t)) {
+                filtered = append (filtered, mov)
+                break
+            }
+        }
+    }
+    return filtered
+}
+
+// needDetails tells whether the detail page of the movies is to be crawled.
+// It is skipped unless asked for by Details or needed for filtering by TitleTypes.
+func needDetails () bool {
+    return opts.Details || len (opts.TitleTypes) > 0
+}
+
+// crawlInline tells whether the detail page of a movie is to be crawled along with
+// parsing its row, rather than later for the movies that pass the chart filters.
+func crawlInline () bool {
+    return needDetails() && !deferDetails()
+}
+
+// deferDetails tells whether the detail pages are crawled once the chart filters
+// are done, as asked for by LazyDetails or as MinRating scans rows beyond the count.
+func deferDetails () bool {
+    return opts.LazyDetails || opts.MinRating > 0
+}
+
+// crawlDetails crawls the detail pages of the given movies concurrently, for the
+// details deferred by LazyDetails.
+func crawlDetails (ctx context.Context, imdbChartTable []ImdbChartData) {
+
+    var wg sync.WaitGroup
+
+    for i := range imdbChartTable {
+        if imdbChartTable[i].DetailURL == "" {
+            continue
+        }
+        wg.Add(1)
+        go func (mov *ImdbChartData) {
+            defer wg.Done()
+            setDetails (&mov.TitleData, fetchMoreInfo (ctx, mov.DetailURL), &mov.FieldErrors)
+            mov.Errors = fieldNames (mov.FieldErrors)
+        }(&imdbChartTable[i])
+    }
+    wg.Wait()
+}
+
+// chartHeading extracts the title of the chart from its page, i.e. the main heading
+// or else the title of the page itself.
+func chartHeading (body string) string {
+
+    if hdStrtIdx := strings.Index(body, `<h1`); hdStrtIdx != -1 {
+        if hdEndIdx := strings.Index(body[hdStrtIdx : ], `</h1>`); hdEndIdx != -1 {
+            return stripTags (body[hdStrtIdx : hdStrtIdx + hdEndIdx])
+        }
+    }
+
+    if tStrtIdx := strings.Index(body, `<title>`); tStrtIdx != -1 {
+        tStrtIdx += len (`<title>`)
+        if tEndIdx := strings.Index(body[tStrtIdx : ], `</title>`); tEndIdx != -1 {
+            return strings.TrimSuffix(strings.TrimSpace(html.UnescapeString(body[tStrtIdx : tStrtIdx + tEndIdx])), " - IMDb")
+        }
+    }
+    return ""
+}
+
+// layoutFor provides the layout of the page at the given URL. The box-office chart
+// is told by the path, so that a trailing slash or a query (e.g. ?ref_=) does not
+// hide it.
+func layoutFor (pageUrl string) listLayout {
+    if strings.HasPrefix (pageUrl, search_url_Keyword) {
+        return keywordLayout
+    }
+    if u, err := url.Parse (pageUrl); err == nil && strings.HasPrefix (u.Path, path_BoxOffice) {
+        return boxOfficeLayout
+    }
+    return chartLayout
+}
+
+// ChartURL provides the URL of the chart of the given name, e.g. tamil for the top
+// rated Tamil movies.
+func ChartURL (name string) (string, error) {
+
+    if chartUrl, ok := namedCharts[strings.ToLower(name)]; ok {
+        return chartUrl, nil
+    }
+    names := []string {}
+    for chartName := range namedCharts {
+        names = append (names, chartName)
+    }
+    sort.Strings (names)
+    return "", fmt.Errorf ("No chart named %q. Should be one of %s", name, strings.Join(names, ", "))
+}
+
+// ValidateURL checks that the given URL is an absolute http(s) URL of a page on IMDb,
+// e.g. https://www.imdb.com/chart/top, or of any host if anyHost is set. The pages
+// other than the keyword search & the box-office chart are taken to have the table
+// layout of the charts (titleColumn, ratingColumn).
+func ValidateURL (pageUrl string, anyHost bool) error {
+
+    u, err := url.Parse (pageUrl)
+    if err != nil {
+        return err
+    }
+    if u.Scheme != "http" && u.Scheme != "https" {
+        return fmt.Errorf ("%q is not an http(s) URL", pageUrl)
+    }
+    if u.Hostname() == "" {
+        return fmt.Errorf ("%q has no host", pageUrl)
+    }
+
+    host := strings.ToLower(u.Hostname())
+    if !anyHost && host != imdb_Host && !strings.HasSuffix (host, "." + imdb_Host) {
+        return fmt.Errorf ("%q is not on %s", pageUrl, imdb_Host)
+    }
+    return nil
+}
+
+// Crawl fetches the chart (or keyword search) at the given URL & crawls up to the
+// given number of its movies (all of them for 0), as per the options set by Configure. The error is that
+// of fetching the chart itself, the failures of the individual movies are reported
+// as warnings & leave their fields empty. Once the context is done (cancelled or past
+// its deadline), the crawl is abandoned with the error of the context.
+func Crawl (ctx context.Context, chartUrl string, itemCount int) (*Chart, error) {
+    return crawl (ctx, fetcher, chartUrl, itemCount, nil)
+}
+
+// CrawlPage is Crawl of the chart page given, e.g. one saved earlier, rather than
+// fetched. The URL is still that of the chart, telling its layout & the links. The
+// detail pages are fetched as usual unless Details is off, the crawl then being
+// entirely offline.
+func CrawlPage (ctx context.Context, chartUrl string, page string, itemCount int) (*Chart, error) {
+    return crawl (ctx, pageFetcher(page), chartUrl, itemCount, nil)
+}
+
+// pageFetcher is the Fetcher serving the one page it is, whatever the URL.
+type pageFetcher string
+
+func (p pageFetcher) Get (ctx context.Context, url string) (string, error) {
+    return string(p), nil
+}
+
+// CrawlStream is Crawl sending each of the movies over the given channel as soon as
+// it is crawled, for the caller to process them before the crawl is over. They are
+// sent in the order of completion rather than that of the chart, as told by the rank.
+// The movies being filtered one by one, SortBy, MinRating & LazyDetails which need
+// all of them are not supported. The channel is closed once the crawl is over, the
+// chart having the movies sent.
+func CrawlStream (ctx context.Context, chartUrl string, itemCount int, movies chan<- ImdbChartData) (*Chart, error) {
+
+    if opts.SortBy != "" || deferDetails() {
+        close (movies)
+        return nil, fmt.Errorf ("The movies cannot be streamed when sorted, filtered by rating or with the details crawled lazily")
+    }
+    return crawl (ctx, fetcher, chartUrl, itemCount, movies)
+}
+
+// crawl is Crawl of the chart obtained via chartFetcher, streaming the movies over
+// movieChan, if given. The channel is closed by the parser of the table, being the
+// sender, or here if it is not started at all.
+func crawl (ctx context.Context, chartFetcher Fetcher, chartUrl string, itemCount int, movieChan chan<- ImdbChartData) (*Chart, error) {
+
+    parsing := false
+    defer func (){
+        if movieChan != nil && !parsing {
+            close (movieChan)
+        }
+    }()
+
+    if itemCount < 0 {
+        return nil, fmt.Errorf ("Invalid count of movies %d. Should not be negative", itemCount)
+    }
+    if err := ctx.Err(); err != nil {
+        return nil, err
+    }
+    ctx = withCrawlState (ctx, &crawlState{
+        normalizeTitles: opts.NormalizeTitle == "on" || (opts.NormalizeTitle == "auto" && rankPrefixCharts[chartUrl]),
+    })
+
+    body, layout, recSlc, err := chartRecords (ctx, chartFetcher, chartUrl, itemCount)
+    if err != nil {
+        return nil, err
+    }
+
+    // Start the master goroutine to parse the table
+    parserChan := make (chan *Chart)
+    parsing = true
+    go parseTableData (ctx, recSlc, layout, chartHeading (body), itemCount, parserChan, movieChan)
+    return awaitChart (ctx, parserChan)
+}
+
+// chartRecords fetches the chart via chartFetcher & splits it into the records of the
+// movies as per its layout, following its pages as needed for the count. The body of
+// the (first) page is provided along.
+func chartRecords (ctx context.Context, chartFetcher Fetcher, chartUrl string, itemCount int) (string, listLayout, []string, error) {
+
+    // Obtain the IMDb result body via http GET request
+    body, err := chartFetcher.Get (ctx, chartUrl)
+    if err != nil {
+        return "", listLayout{}, nil, err
+    }
+    info ("Fetched the chart", chartUrl)
+
+    // only extract the table containing the movie list, a page without one being
+    // an error page or of a layout not known
+    layout := layoutFor (chartUrl)
+    table := layout.list (body)
+    if table == "" {
+        return "", listLayout{}, nil, ErrNoMovieList
+    }
+    recSlc := layout.rows (table)
+
+    // the rows of the further pages of a paginated list, unless the page is given
+    // rather than fetched, the given one being all there is
+    if _, given := chartFetcher.(pageFetcher); !given {
+        recSlc = append (recSlc, nextPagesRows (ctx, chartFetcher, layout, chartUrl, body, len (recSlc), itemCount)...)
+    }
+    return body, layout, recSlc, nil
+}
+
+// CountMovies provides the number of the movies the chart at the given URL lists (all
+// its pages, up to RankTo if given), without crawling any of them, e.g. to size a
+// crawl beforehand.
+func CountMovies (ctx context.Context, chartUrl string) (int, error) {
+
+    _, _, recSlc, err := chartRecords (ctx, fetcher, chartUrl, 0)
+    if err != nil {
+        return 0, err
+    }
+    if opts.RankTo > 0 && opts.RankTo < len (recSlc) {
+        return opts.RankTo, nil
+    }
+    return len (recSlc), nil
+}
+
+// ErrNoMovieList is the error of a crawl of a page not having the list of the movies,
+// e.g. an error page or a page whose layout has changed.
+var ErrNoMovieList = errors.New ("movie table not found, layout may have changed")
+
+// CrawlIDs crawls the titles having the IMDb title IDs (e.g. tt0093603) of the given
+// lines of a list of IDs, same as the movies of a chart, in the order given. The lines
+// without an ID are counted in the Failures of the chart. See ids.go
+func CrawlIDs (ctx context.Context, lines []string) (*Chart, error) {
+
+    if err := ctx.Err(); err != nil {
+        return nil, err
+    }
+    ctx = withCrawlState (ctx, &crawlState{normalizeTitles: opts.NormalizeTitle == "on"})
+
+    // the IDs are parsed before the movies, hence their failures are counted here
+    failuresBefore := atomic.LoadInt64(&failureCount)
+    layout := newIDsLayout()
+    ids := layout.rows (strings.Join(lines, "\n"))
+    malformed := int(atomic.LoadInt64(&failureCount) - failuresBefore)
+
+    parserChan := make (chan *Chart)
+    go parseTableData (ctx, ids, layout, "", len (ids), parserChan, nil)
+    chart, err := awaitChart (ctx, parserChan)
+    if err != nil {
+        return nil, err
+    }
+    chart.Failures += malformed
+    return chart, nil
+}
+
+// awaitChart waits for the master goroutine to send the crawled chart, giving up
+// with the error of the context once it is done. The outstanding fetches fail then
+// as they are made with the context, so the goroutines wind up promptly.
+func awaitChart (ctx context.Context, parserChan <-chan *Chart) (*Chart, error) {
+    select {
+    case chart := <-parserChan:
+        return chart, nil
+    case <-ctx.Done():
+        return nil, ctx.Err()
+    }
+}
+
+// FetchChart provides up to the given number of movies of the chart at the given URL,
+// crawled as per the options set by Configure. See Crawl
+func FetchChart (ctx context.Context, chartUrl string, count int) ([]ImdbChartData, error) {
+
+    chart, err := Crawl (ctx, chartUrl, count)
+    if err != nil {
+        return nil, err
+    }
+    return chart.Movies, nil
+}
+
+// EncodeChart is FetchChart writing the movies to w as a JSON array, e.g. to an HTTP
+// response or a gzip.Writer, rather than providing them. The movies are encoded one
+// at a time, so that the whole of the JSON is never held in memory. Nothing is
+// written if the crawl fails.
+func EncodeChart (ctx context.Context, w io.Writer, chartUrl string, count int) error {
+
+    movies, err := FetchChart (ctx, chartUrl, count)
+    if err != nil {
+        return err
+    }
+
+    enc := json.NewEncoder (w)
+    sep := "["
+    for _, mov := range movies {
+        if _, err = io.WriteString (w, sep); err != nil {
+            return err
+        }
+        if err = enc.Encode (mov); err != nil {
+            return err
+        }
+        sep = ","
+    }
+    if sep == "[" {
+        _, err = io.WriteString (w, "[]\n")
+    } else {
+        _, err = io.WriteString (w, "]\n")
+    }
+    return err
+}
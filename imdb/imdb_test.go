@@ -0,0 +1,917 @@
+/*
+ *-----------------------------------------------------------------
+ * IMDb Chart Fetcher - Tests of the parsing
+ *-----------------------------------------------------------------
+ * Description: The parsing of the chart rows & the detail pages, fed
+ *              with the HTML fixtures of testdata (a trimmed chart &
+ *              detail pages of the layout of the site) or the rows
+ *              given inline, without any request to IMDb. The pages a
+ *              crawl fetches are served by MapFetcher.
+ *-----------------------------------------------------------------
+ */
+package imdb
+
+import (
+    "fmt"
+    "sync"
+    "errors"
+    "context"
+    "reflect"
+    "strings"
+    "testing"
+    "net/http"
+    "io/ioutil"
+    "encoding/json"
+    "path/filepath"
+    "net/http/httptest"
+)
+
+// URLs of the detail pages of the fixture chart
+const (
+    testURL_Detail1 = imdb_url_Main + "/title/tt0000001/"
+    testURL_Detail2 = imdb_url_Main + "/title/tt0000002/"
+    testURL_Detail3 = imdb_url_Main + "/title/tt0000003/"
+)
+
+// fixture provides the content of the given file of testdata.
+func fixture (t *testing.T, name string) string {
+
+    t.Helper()
+    data, err := ioutil.ReadFile (filepath.Join("testdata", name))
+    if err != nil {
+        t.Fatal (err)
+    }
+    return string(data)
+}
+
+// detailPages provides the detail pages of the fixture chart, keyed by their URL.
+func detailPages (t *testing.T) map[string]string {
+    return map[string]string {
+        testURL_Detail1: fixture (t, "detail1.html"),
+        testURL_Detail2: fixture (t, "detail2.html"),
+        testURL_Detail3: fixture (t, "detail3.html"),
+    }
+}
+
+// configureTest puts the given options in effect with the pages served from memory,
+// the defaults being back in effect once the test is over.
+func configureTest (t *testing.T, o Options, pages map[string]string) {
+    t.Helper()
+    configureFetcher (t, o, MapFetcher (pages))
+}
+
+// configureFetcher is configureTest with the pages served by the given fetcher.
+func configureFetcher (t *testing.T, o Options, f Fetcher) {
+
+    t.Helper()
+    o.Fetcher = f
+    if err := Configure (o); err != nil {
+        t.Fatal (err)
+    }
+    t.Cleanup (func (){
+        if err := Configure (DefaultOptions()); err != nil {
+            t.Fatal (err)
+        }
+    })
+}
+
+// parseChart runs parseTableData on the rows of the given chart page as per the
+// chart layout.
+func parseChart (t *testing.T, page string, count int) *Chart {
+
+    t.Helper()
+    parserChan := make (chan *Chart, 1)
+    parseTableData (context.Background(), chartRows (chartTable (page)), chartLayout, "Chart", count, parserChan, nil)
+    return <-parserChan
+}
+
+func TestParseTableData (t *testing.T) {
+
+    type movie struct {
+        rank   int
+        id     string
+        title  string
+        year   uint64
+        rating float64
+        votes  uint64
+        genre  string
+    }
+    tests := []struct {
+        name      string
+        opts      func (o *Options)
+        count     int
+        available int
+        want      []movie
+    }{
+        {
+            name:      "chart only",
+            opts:      func (o *Options){ o.Details = false },
+            count:     0,
+            available: 3,
+            want: []movie {
+                {1, "tt0000001", "Nayakan", 1987, 8.6, 20000, ""},
+                {2, "tt0000002", "Anbe Sivam", 2003, 8.7, 15000, ""},
+                {3, "tt0000003", "Tom & Jerry", 2019, 7.9, 900, ""},
+            },
+        },
+        {
+            name:      "with details",
+            opts:      func (o *Options){},
+            count:     2,
+            available: 3,
+            want: []movie {
+                {1, "tt0000001", "Nayakan", 1987, 8.6, 20000, "Crime, Drama"},
+                {2, "tt0000002", "Anbe Sivam", 2003, 8.7, 15000, "Crime, Drama"},
+            },
+        },
+        {
+            name:      "rank window",
+            opts:      func (o *Options){ o.Details = false; o.RankFrom = 2 },
+            count:     5,
+            available: 2,
+            want: []movie {
+                {2, "tt0000002", "Anbe Sivam", 2003, 8.7, 15000, ""},
+                {3, "tt0000003", "Tom & Jerry", 2019, 7.9, 900, ""},
+            },
+        },
+        {
+            name:      "first passing the rating & the baseline",
+            opts:      func (o *Options){ o.Details = false; o.MinRating = 7; o.Baseline = map[string]bool {"tt0000001": true} },
+            count:     2,
+            available: 3,
+            want: []movie {
+                {2, "tt0000002", "Anbe Sivam", 2003, 8.7, 15000, ""},
+                {3, "tt0000003", "Tom & Jerry", 2019, 7.9, 900, ""},
+            },
+        },
+        {
+            name:      "first passing the rating & the title type",
+            opts:      func (o *Options){ o.MinRating = 7; o.TitleTypes = []string {"Movie"} },
+            count:     2,
+            available: 3,
+            want: []movie {
+                {1, "tt0000001", "Nayakan", 1987, 8.6, 20000, "Crime, Drama"},
+                {3, "tt0000003", "Tom & Jerry", 2019, 7.9, 900, "Thriller, Drama"},
+            },
+        },
+        {
+            name:      "sorted by rating",
+            opts:      func (o *Options){ o.Details = false; o.SortBy = "-rating" },
+            count:     0,
+            available: 3,
+            want: []movie {
+                {2, "tt0000002", "Anbe Sivam", 2003, 8.7, 15000, ""},
+                {1, "tt0000001", "Nayakan", 1987, 8.6, 20000, ""},
+                {3, "tt0000003", "Tom & Jerry", 2019, 7.9, 900, ""},
+            },
+        },
+    }
+
+    page := fixture (t, "chart.html")
+    for _, tt := range tests {
+        t.Run (tt.name, func (t *testing.T) {
+            o := DefaultOptions()
+            tt.opts (&o)
+            configureTest (t, o, detailPages (t))
+
+            chart := parseChart (t, page, tt.count)
+            if chart.AvailableCount != tt.available {
+                t.Errorf ("available %d, want %d", chart.AvailableCount, tt.available)
+            }
+            if len (chart.Movies) != len (tt.want) {
+                t.Fatalf ("%d movies, want %d", len (chart.Movies), len (tt.want))
+            }
+            for i, mov := range chart.Movies {
+                got := movie{mov.Rank, mov.IMDbID, mov.Title, mov.ReleaseYear, mov.Rating, mov.Votes, mov.Genre}
+                if got != tt.want[i] {
+                    t.Errorf ("movie %d is %+v, want %+v", i, got, tt.want[i])
+                }
+                if len (mov.Errors) != 0 {
+                    t.Errorf ("movie %d has errors %v", i, mov.Errors)
+                }
+            }
+        })
+    }
+}
+
+func TestGetRating (t *testing.T) {
+
+    tests := []struct {
+        name   string
+        row    string
+        rating float64
+        votes  uint64
+        errs   []string
+    }{
+        {
+            name:   "rating & votes",
+            row:    `<td class="ratingColumn imdbRating"><strong title="8.6 based on 20,000 user ratings">8.6</strong></td>`,
+            rating: 8.6,
+            votes:  20000,
+        },
+        {
+            name:   "decimal comma & padding",
+            row:    `<td class="ratingColumn imdbRating"><strong title="8,6 based on 1,234,567 user ratings">&nbsp;8,6
+            </strong></td>`,
+            rating: 8.6,
+            votes:  1234567,
+        },
+        {
+            name:   "no votes",
+            row:    `<td class="ratingColumn imdbRating"><strong>7.1</strong></td>`,
+            rating: 7.1,
+            errs:   []string {field_Votes},
+        },
+        {
+            name:   "no rating",
+            row:    `<td class="ratingColumn imdbRating"><strong title="based on 12 user ratings"></strong></td>`,
+            votes:  12,
+            errs:   []string {field_Rating},
+        },
+        {
+            name:   "rating column not closed",
+            row:    `<td class="ratingColumn imdbRating"><strong title="8.6 based on 20,000 user ratings">8.6</strong>`,
+            rating: 8.6,
+            votes:  20000,
+        },
+        {
+            name:   "no strong",
+            row:    `<td class="ratingColumn imdbRating">8.6</td>`,
+            errs:   []string {field_Rating, field_Votes},
+        },
+        {
+            name:   "strong not closed",
+            row:    `<td class="ratingColumn imdbRating"><strong title="8.6 based on 20,000 user ratings">8.6</td>`,
+            votes:  20000,
+            errs:   []string {field_Rating},
+        },
+        {
+            name:   "nothing after the column",
+            row:    `<td class="ratingColumn imdbRating">`,
+            errs:   []string {field_Rating, field_Votes},
+        },
+        {
+            name:   "no rating column",
+            row:    `<td class="titleColumn"><a href="/title/tt0000001/">Nayakan</a></td>`,
+            errs:   []string {field_Rating, field_Votes},
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run (tt.name, func (t *testing.T) {
+            var (
+                rating float64
+                votes  uint64
+                errs   []FieldError
+                wg     sync.WaitGroup
+            )
+            wg.Add(1)
+            getRating (context.Background(), tt.row, &rating, &votes, &errs, &wg)
+            if rating != tt.rating || votes != tt.votes {
+                t.Errorf ("rating %v & votes %d, want %v & %d", rating, votes, tt.rating, tt.votes)
+            }
+            if !reflect.DeepEqual (fieldNames (errs), tt.errs) {
+                t.Errorf ("errors %v, want %v", errs, tt.errs)
+            }
+        })
+    }
+}
+
+func TestParseRating (t *testing.T) {
+
+    tests := []struct {
+        text   string
+        rating float64
+        ok     bool
+    }{
+        {"8.6", 8.6, true},
+        {" 8.6\n\t", 8.6, true},
+        {"&nbsp;8.6", 8.6, true},
+        {"8.6&#160;", 8.6, true},
+        {"\u00a08.6", 8.6, true},
+        {"8,6", 8.6, true},
+        {"&nbsp; 8,6 \n", 8.6, true},
+        {"10", 10, true},
+        {"", 0, false},
+        {"&nbsp;", 0, false},
+        {"N/A", 0, false},
+    }
+
+    for _, tt := range tests {
+        rating, err := parseRating (tt.text)
+        if (err == nil) != tt.ok || (tt.ok && rating != tt.rating) {
+            t.Errorf ("parseRating(%q) = %v, %v, want %v", tt.text, rating, err, tt.rating)
+        }
+    }
+}
+
+func TestParseMoreInfo (t *testing.T) {
+
+    tests := []struct {
+        name     string
+        page     string
+        opts     func (o *Options)
+        want     MovDetail
+    }{
+        {
+            name: "movie",
+            page: "detail1.html",
+            opts: func (o *Options){},
+            want: MovDetail{
+                Summary:         "A common man's struggle against a corrupt police force...",
+                Duration:        "2h 21min",
+                DurationMinutes: 141,
+                Genre:           "Crime, Drama",
+                TitleType:       "Movie",
+                Directors:       []string {"Mani Ratnam"},
+                Stars:           []string {"Kamal Haasan", "Saranya"},
+            },
+        },
+        {
+            name: "TV series, pretty duration",
+            page: "detail2.html",
+            opts: func (o *Options){ o.PrettyDuration = true },
+            want: MovDetail{
+                Summary:         "A common man's struggle against a corrupt police force, number 2.",
+                Duration:        "2h 22m",
+                DurationMinutes: 142,
+                Genre:           "Crime, Drama",
+                TitleType:       "TVSeries",
+            },
+        },
+        {
+            name: "credit summary, truncated summary",
+            page: "detail3.html",
+            opts: func (o *Options){ o.MaxSummaryLength = 13 },
+            want: MovDetail{
+                Summary:         "A common…",
+                Duration:        "2h 23min",
+                DurationMinutes: 143,
+                Genre:           "Thriller, Drama",
+                TitleType:       "Movie",
+                Directors:       []string {"Dir One", "Dir Two"},
+                Stars:           []string {"Star A", "Star B"},
+            },
+        },
+        {
+            name: "genres before the runtime",
+            page: "reordered.html",
+            opts: func (o *Options){},
+            want: MovDetail{
+                Summary:         "The genres before the runtime, without any separator.",
+                Duration:        "2h 25min",
+                DurationMinutes: 145,
+                Genre:           "Action, Crime",
+            },
+        },
+        {
+            name: "entities",
+            page: "entities.html",
+            opts: func (o *Options){},
+            want: MovDetail{
+                Summary:         "Tom & Jerry's \"chase\" in a hotel – <the> feud.",
+                Duration:        "1h 41min",
+                DurationMinutes: 101,
+                Genre:           "Sci\u2011Fi & Fantasy, Comedy",
+                TitleType:       "Movie",
+            },
+        },
+        {
+            name: "no summary, nor runtime",
+            page: "nosummary.html",
+            opts: func (o *Options){},
+            want: MovDetail{
+                Genre:           "Drama",
+                TitleType:       "Movie",
+                Directors:       []string {"Mani Ratnam"},
+            },
+        },
+        {
+            name: "no details",
+            page: "",
+            opts: func (o *Options){},
+            want: MovDetail{},
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run (tt.name, func (t *testing.T) {
+            o := DefaultOptions()
+            tt.opts (&o)
+            configureTest (t, o, nil)
+
+            page := "<html><body></body></html>"
+            if tt.page != "" {
+                page = fixture (t, tt.page)
+            }
+            // as encoded, the empty lists being the same as none
+            got, _ := json.Marshal (parseMoreInfo (context.Background(), testURL_Detail1, page))
+            want, _ := json.Marshal (tt.want)
+            if string(got) != string(want) {
+                t.Errorf ("details\n%s\nwant\n%s", got, want)
+            }
+        })
+    }
+}
+
+func TestSummaryText (t *testing.T) {
+
+    tests := []struct {
+        name string
+        page string
+        want string
+    }{
+        {"summary & link", fixture (t, "detail1.html"), "A common man's struggle against a corrupt police force..."},
+        {"no summary div", fixture (t, "nosummary.html"), ""},
+        {"summary div not closed", `<div class="summary_text"> A common man's struggle`, ""},
+        {"other div closed before", `</div><p>Nayakan</p><div class="summary_text">`, ""},
+        {"empty summary", `<div class="summary_text">  </div>`, ""},
+        {"no page", ``, ""},
+    }
+
+    for _, tt := range tests {
+        t.Run (tt.name, func (t *testing.T) {
+            if got := summaryText (tt.page); got != tt.want {
+                t.Errorf ("summary %q, want %q", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestGenreLinks (t *testing.T) {
+
+    tests := []struct {
+        name string
+        page string
+        want []string
+    }{
+        {"separated by the ghosts", fixture (t, "detail3.html"), []string {"Thriller", "Drama"}},
+        {"before the runtime & repeated", fixture (t, "reordered.html"), []string {"Action", "Crime"}},
+        {"genres query not first", `<a href="/search/title?title_type=feature&amp;genres=horror">Horror</a>`, []string {"Horror"}},
+        {"nested tags & entities", `<a class="x" href="/search/title/?genres=sci-fi"><span>Sci-Fi &amp; <i>Fantasy</i></span></a>`, []string {"Sci-Fi & Fantasy"}},
+        {"no genres", `<a href="/search/keyword?keywords=gangster">Gangster</a><a href="/search/title?genres=">  </a>`, []string {}},
+    }
+
+    for _, tt := range tests {
+        t.Run (tt.name, func (t *testing.T) {
+            if got := genreLinks (tt.page); !reflect.DeepEqual (got, tt.want) {
+                t.Errorf ("genres %q, want %q", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestPlotSummaryURL (t *testing.T) {
+
+    tests := []struct {
+        cUrl string
+        want string
+    }{
+        {imdb_url_Main + "/title/tt0000001/", imdb_url_Main + "/title/tt0000001/plotsummary"},
+        {imdb_url_Main + "/title/tt0000001", imdb_url_Main + "/title/tt0000001/plotsummary"},
+        {imdb_url_Main + "/title/tt0000001/?ref_=chttp_t_1", imdb_url_Main + "/title/tt0000001/plotsummary"},
+        {"https://example.com/movie/?ref_=x", "https://example.com/movie/plotsummary"},
+    }
+
+    for _, tt := range tests {
+        if got := plotSummaryURL (tt.cUrl); got != tt.want {
+            t.Errorf ("plotSummaryURL(%q) = %q, want %q", tt.cUrl, got, tt.want)
+        }
+    }
+}
+
+func TestStoryline (t *testing.T) {
+
+    o := DefaultOptions()
+    o.Storyline = true
+    configureTest (t, o, map[string]string {
+        imdb_url_Main + "/title/tt0000001/plotsummary": fixture (t, "plot.html"),
+    })
+
+    // the detail URL as linked by the chart, with its query
+    d := parseMoreInfo (context.Background(), testURL_Detail1 + "?ref_=chttp_t_1", fixture (t, "detail1.html"))
+    want := "A much longer storyline about a common man who rises to become a feared don in Bombay."
+    if d.Storyline != want {
+        t.Errorf ("storyline %q, want %q", d.Storyline, want)
+    }
+}
+
+// the cap of the storyline requests is of each crawl, the crawls of the same process
+// getting as many storylines as the first one
+func TestStorylineCap (t *testing.T) {
+
+    o := DefaultOptions()
+    o.Storyline = true
+    o.MaxStorylineRequests = 2
+    pages := detailPages (t)
+    pages[chart_url_Tamil] = fixture (t, "chart.html")
+    for _, id := range []string {"tt0000001", "tt0000002", "tt0000003"} {
+        pages[imdb_url_Main + "/title/" + id + "/plotsummary"] = fixture (t, "plot.html")
+    }
+    configureTest (t, o, pages)
+
+    for crawl := 1; crawl <= 2; crawl++ {
+        chart, err := Crawl (context.Background(), chart_url_Tamil, 0)
+        if err != nil {
+            t.Fatal (err)
+        }
+        storylines := 0
+        for _, mov := range chart.Movies {
+            if mov.Storyline != "" {
+                storylines++
+            }
+        }
+        if storylines != o.MaxStorylineRequests {
+            t.Errorf ("crawl %d has %d storylines, want %d", crawl, storylines, o.MaxStorylineRequests)
+        }
+    }
+}
+
+func TestParseTitleRow (t *testing.T) {
+
+    tests := []struct {
+        name  string
+        row   string
+        want  TitleData
+        errs  []string
+    }{
+        {
+            name: "title & year",
+            row:  `<td class="titleColumn">1. <a href="/title/tt0000001/" title="Mani Ratnam (dir.)">Nayakan</a> <span class="secondaryInfo">(1987)</span></td>`,
+            want: TitleData{IMDbID: "tt0000001", Title: "Nayakan", ReleaseYear: 1987, DetailURL: testURL_Detail1},
+        },
+        {
+            name: "link with a query",
+            row:  `<td class="titleColumn"><a href="/title/tt0000001/?ref_=chttp_t_1">Nayakan</a> <span class="secondaryInfo">(1987)</span></td>`,
+            want: TitleData{IMDbID: "tt0000001", Title: "Nayakan", ReleaseYear: 1987, DetailURL: testURL_Detail1},
+        },
+        {
+            name: "entities in the title",
+            row:  `<td class="titleColumn"><a href="/title/tt0000003/">Tom &amp; Jerry&#39;s &quot;Chase&quot;</a> <span class="secondaryInfo">(2019)</span></td>`,
+            want: TitleData{IMDbID: "tt0000003", Title: `Tom & Jerry's "Chase"`, ReleaseYear: 2019, DetailURL: testURL_Detail3},
+        },
+        {
+            name: "award badge after the title",
+            row:  `<td class="titleColumn">1. <a href="/title/tt0000001/">Nayakan</a> <a href="/awards/?ref_=badge"><span class="badge">Winner</span></a> <span class="secondaryInfo">(1987)</span></td>`,
+            want: TitleData{IMDbID: "tt0000001", Title: "Nayakan", ReleaseYear: 1987, DetailURL: testURL_Detail1},
+        },
+        {
+            name: "badge within the title link",
+            row:  `<td class="titleColumn"><a href="/title/tt0000001/">Nayakan <span class="badge">New</span></a><span class="secondaryInfo">(1987)</span> <span class="badge">(1)</span></td>`,
+            want: TitleData{IMDbID: "tt0000001", Title: "Nayakan New", ReleaseYear: 1987, DetailURL: testURL_Detail1},
+        },
+        {
+            name: "title link not closed",
+            row:  `<td class="titleColumn"><a href="/title/tt0000001/">Nayakan <span class="secondaryInfo">(1987)</span></td>`,
+            want: TitleData{IMDbID: "tt0000001", DetailURL: testURL_Detail1},
+            errs: []string {field_Title},
+        },
+        {
+            name: "no link",
+            row:  `<td class="titleColumn">Nayakan <span class="secondaryInfo">(1987)</span></td>`,
+            errs: []string {field_Title},
+        },
+        {
+            name: "link not closed",
+            row:  `<td class="titleColumn"><a href="/title/tt0000001/</td>`,
+            errs: []string {field_Title},
+        },
+        {
+            name: "no title column",
+            row:  `<td class="ratingColumn imdbRating"><strong>8.6</strong></td>`,
+            errs: []string {field_Title},
+        },
+        {
+            name: "title column not closed",
+            row:  `<td class="titleColumn"><a href="/title/tt0000001/">Nayakan</a> <span class="secondaryInfo">(1987)</span>`,
+            want: TitleData{IMDbID: "tt0000001", Title: "Nayakan", ReleaseYear: 1987, DetailURL: testURL_Detail1},
+        },
+        {
+            name: "no year",
+            row:  `<td class="titleColumn"><a href="/title/tt0000001/">Nayakan</a></td>`,
+            want: TitleData{IMDbID: "tt0000001", Title: "Nayakan", DetailURL: testURL_Detail1},
+            errs: []string {field_ReleaseYear},
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run (tt.name, func (t *testing.T) {
+            var (
+                got  TitleData
+                errs []FieldError
+            )
+            parseTitleRow (tt.row, &got, &errs)
+            if !reflect.DeepEqual (got, tt.want) {
+                t.Errorf ("title data %+v, want %+v", got, tt.want)
+            }
+            if !reflect.DeepEqual (fieldNames (errs), tt.errs) {
+                t.Errorf ("errors %v, want %v", errs, tt.errs)
+            }
+        })
+    }
+}
+
+// TestParseMoreInfoConcurrent parses the detail pages concurrently with the storyline
+// & the translation fetched by goroutines of their own, for go test -race to tell any
+// data race among them.
+func TestParseMoreInfoConcurrent (t *testing.T) {
+
+    endpoint := httptest.NewServer (http.HandlerFunc(func (w http.ResponseWriter, r *http.Request) {
+        var req translateRequest
+        if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+            http.Error (w, err.Error(), http.StatusBadRequest)
+            return
+        }
+        json.NewEncoder(w).Encode (map[string]string {"translatedText": req.Target + ": " + req.Text})
+    }))
+    defer endpoint.Close()
+
+    o := DefaultOptions()
+    o.Storyline = true
+    o.TranslateTo = "ta"
+    o.TranslateURL = endpoint.URL
+    pages := detailPages (t)
+    for pageUrl := range detailPages (t) {
+        pages[plotSummaryURL (pageUrl)] = fixture (t, "plot.html")
+    }
+    configureTest (t, o, pages)
+
+    var wg sync.WaitGroup
+    details := make ([]MovDetail, 12)
+    for i := range details {
+        wg.Add(1)
+        go func (i int) {
+            defer wg.Done()
+            pageUrl := []string {testURL_Detail1, testURL_Detail2, testURL_Detail3}[i % 3]
+            details[i] = fetchMoreInfo (context.Background(), pageUrl)
+        }(i)
+    }
+    wg.Wait()
+
+    for i, d := range details {
+        if d.Summary == "" || d.Storyline == "" || d.TranslatedSummary != "ta: " + d.Summary {
+            t.Errorf ("details %d incomplete: %+v", i, d)
+        }
+    }
+}
+
+// failingFetcher is the Fetcher failing for every URL but those of the given pages,
+// as for a network that is down.
+type failingFetcher map[string]string
+
+var errFetch = errors.New ("dial tcp: connection refused")
+
+func (f failingFetcher) Get (ctx context.Context, url string) (string, error) {
+    if page, ok := f[url]; ok {
+        return page, nil
+    }
+    return "", errFetch
+}
+
+// the titles prefixed with the rank, normalized only by the crawl of a chart known for
+// it, while another crawl of the same page is running
+func TestNormalizeTitlesPerCrawl (t *testing.T) {
+
+    o := DefaultOptions()
+    o.Details = false
+    configureTest (t, o, nil)
+
+    page := `<table><tr><td class="titleColumn"><a href="/title/tt0000001/">1. Nayakan</a> <span class="secondaryInfo">(1987)</span></td></tr></table>`
+    tests := map[string]string {
+        chart_url_Tamil:              "Nayakan",
+        imdb_url_Main + "/chart/top": "1. Nayakan",
+    }
+
+    var wg sync.WaitGroup
+    for chartUrl, want := range tests {
+        for i := 0; i < 10; i++ {
+            wg.Add(1)
+            go func (chartUrl, want string) {
+                defer wg.Done()
+                chart, err := CrawlPage (context.Background(), chartUrl, page, 0)
+                if err != nil {
+                    t.Error (err)
+                    return
+                }
+                if got := chart.Movies[0].Title; got != want {
+                    t.Errorf ("%s: title %q, want %q", chartUrl, got, want)
+                }
+            }(chartUrl, want)
+        }
+    }
+    wg.Wait()
+}
+
+func TestCrawlCount (t *testing.T) {
+
+    pages := detailPages (t)
+    pages[chart_url_Tamil] = fixture (t, "chart.html")
+    configureTest (t, DefaultOptions(), pages)
+
+    // a count of 0 is for all the movies, & one beyond the chart is clamped to it
+    for count, want := range map[int]int {0: 3, 1: 1, 3: 3, 5: 3} {
+        chart, err := Crawl (context.Background(), chart_url_Tamil, count)
+        if err != nil {
+            t.Fatalf ("count %d: %v", count, err)
+        }
+        if len (chart.Movies) != want || chart.AvailableCount != 3 {
+            t.Errorf ("count %d: %d of %d movies, want %d of 3", count, len (chart.Movies), chart.AvailableCount, want)
+        }
+    }
+
+    for _, count := range []int {-1, -250} {
+        if chart, err := Crawl (context.Background(), chart_url_Tamil, count); err == nil {
+            t.Errorf ("count %d: %d movies, want an error", count, len (chart.Movies))
+        }
+    }
+}
+
+func TestCrawlFetchErrors (t *testing.T) {
+
+    chartUrl := chart_url_Tamil
+    tests := []struct {
+        name     string
+        pages    failingFetcher
+        err      error
+        movies   int
+        failures int
+        errs     []string
+    }{
+        {
+            name: "chart",
+            err:  errFetch,
+        },
+        {
+            name:     "detail pages",
+            pages:    failingFetcher{chartUrl: fixture (t, "chart.html")},
+            movies:   3,
+            failures: 3,
+            errs:     []string {field_Details},
+        },
+        {
+            name:   "no movie list",
+            pages:  failingFetcher{chartUrl: "<html><body>Service Unavailable</body></html>"},
+            err:    ErrNoMovieList,
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run (tt.name, func (t *testing.T) {
+            configureFetcher (t, DefaultOptions(), tt.pages)
+
+            chart, err := Crawl (context.Background(), chartUrl, 0)
+            if err != tt.err {
+                t.Fatalf ("error %v, want %v", err, tt.err)
+            }
+            if err != nil {
+                return
+            }
+            if len (chart.Movies) != tt.movies || chart.Failures != tt.failures {
+                t.Errorf ("%d movies & %d failures, want %d & %d", len (chart.Movies), chart.Failures, tt.movies, tt.failures)
+            }
+            for i, mov := range chart.Movies {
+                if !reflect.DeepEqual (mov.Errors, tt.errs) {
+                    t.Errorf ("movie %d has errors %v, want %v", i, mov.Errors, tt.errs)
+                }
+                if mov.Title == "" {
+                    t.Errorf ("movie %d lost its title", i)
+                }
+            }
+        })
+    }
+}
+
+func TestChartTable (t *testing.T) {
+
+    table := `<table class="chart"><tbody><tr><td>Nayakan</td></tr></tbody></table>`
+    tests := []struct {
+        name string
+        page string
+        want string
+    }{
+        {"table", `<html><body><h1>Chart</h1>` + table + `</body></html>`, table},
+        {"no table", `<html><body><div class="lister-list"></div></body></html>`, ""},
+        {"no page", ``, ""},
+        {"table not closed", `<html><body><table class="chart"><tr><td>Nayakan</td></tr></body></html>`, ""},
+        {"closed before opened", `<html><body></table><p>Nayakan</p><table class="chart"></body></html>`, ""},
+    }
+
+    for _, tt := range tests {
+        t.Run (tt.name, func (t *testing.T) {
+            if got := chartTable (tt.page); got != tt.want {
+                t.Errorf ("table %q, want %q", got, tt.want)
+            }
+            // a page without the table, e.g. an error page, is not a chart
+            if _, err := CrawlPage (context.Background(), chart_url_Tamil, tt.page, 0); tt.want == "" && err != ErrNoMovieList {
+                t.Errorf ("error %v, want %v", err, ErrNoMovieList)
+            }
+        })
+    }
+}
+
+func TestChartRows (t *testing.T) {
+
+    row := func (n int) string {
+        return fmt.Sprintf (`<td class="titleColumn"><a href="/title/tt000000%d/">Movie %d</a></td></tr>`, n, n)
+    }
+    tests := []struct {
+        name  string
+        table string
+        rows  int
+    }{
+        {
+            name:  "header in thead",
+            table: `<table><thead><tr><th>Rank &amp; Title</th><th>IMDb Rating</th></tr></thead><tbody><tr>` + row(1) + `<tr>` + row(2) + `</tbody></table>`,
+            rows:  2,
+        },
+        {
+            name:  "header without thead",
+            table: `<table><tr><th class="title">Title</th><th>Rating</th></tr><tr>` + row(1) + `<tr>` + row(2) + `<tr>` + row(3) + `</table>`,
+            rows:  3,
+        },
+        {
+            name:  "no header",
+            table: `<table><tbody><tr>` + row(1) + `<tr>` + row(2) + `</tbody></table>`,
+            rows:  2,
+        },
+        {
+            name:  "no rows",
+            table: `<table></table>`,
+            rows:  0,
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run (tt.name, func (t *testing.T) {
+            rows := chartRows (tt.table)
+            if len (rows) != tt.rows {
+                t.Fatalf ("%d rows, want %d: %q", len (rows), tt.rows, rows)
+            }
+            for i, r := range rows {
+                if !strings.Contains (r, fmt.Sprintf ("Movie %d<", i + 1)) {
+                    t.Errorf ("row %d is %q", i, r)
+                }
+            }
+        })
+    }
+}
+
+func TestDetectInterstitial (t *testing.T) {
+
+    tests := []struct {
+        name string
+        body string
+        want string
+    }{
+        {"consent title", `<html><head><TITLE>Before you continue to IMDb</TITLE></head></html>`, interstitial_Consent},
+        {"consent form", `<form method="post" action="https://consent.imdb.com/save">`, interstitial_Consent},
+        {"consent page", `<div id="consent-page">`, interstitial_Consent},
+        {"age gate title", `<title>Age Verification</title>`, interstitial_AgeGate},
+        {"age gate", `<section id="age-gate">`, interstitial_AgeGate},
+        {"link to the consent", `<title>Top Rated Movies</title><footer><a href="https://consent.imdb.com/privacy">Privacy</a></footer>`, ""},
+        {"age in the text", `<title>Nayakan (1987)</title><p>You must be 18 or older to view this? Not here.</p>`, ""},
+    }
+
+    for _, tt := range tests {
+        t.Run (tt.name, func (t *testing.T) {
+            if got := detectInterstitial (tt.body); got != tt.want {
+                t.Errorf ("interstitial %q, want %q", got, tt.want)
+            }
+        })
+    }
+}
+
+func TestSortMoviesStable (t *testing.T) {
+
+    movie := func (rank int, rating float64, year uint64) ImdbChartData {
+        return ImdbChartData{Rank: rank, Rating: rating, TitleData: TitleData{ReleaseYear: year}}
+    }
+    tests := []struct {
+        key   string
+        ranks []int
+    }{
+        {"rating", []int {3, 6, 1, 2, 5, 4}},
+        {"-rating", []int {4, 1, 2, 5, 3, 6}},
+        {"rating-desc", []int {4, 1, 2, 5, 3, 6}},
+        {"year", []int {1, 3, 5, 6, 2, 4}},
+        {"rank", []int {1, 2, 3, 4, 5, 6}},
+    }
+
+    for _, tt := range tests {
+        // the ties of each key in the chart order, whatever the number of runs
+        for run := 0; run < 10; run++ {
+            movies := []ImdbChartData {
+                movie(1, 8.5, 1987), movie(2, 8.5, 2003), movie(3, 7.9, 1987),
+                movie(4, 9.0, 2019), movie(5, 8.5, 1987), movie(6, 7.9, 1987),
+            }
+            sortMovies (movies, tt.key, true)
+            ranks := []int {}
+            for _, mov := range movies {
+                ranks = append (ranks, mov.Rank)
+            }
+            if !reflect.DeepEqual (ranks, tt.ranks) {
+                t.Fatalf ("sorted by %s to %v, want %v", tt.key, ranks, tt.ranks)
+            }
+        }
+    }
+}
@@ -0,0 +1,56 @@
+//go:build integration
+// +build integration
+
+/*
+ *-----------------------------------------------------------------
+ * IMDb Chart Fetcher - Integration tests
+ *-----------------------------------------------------------------
+ * Description: Crawls the live charts of IMDb, to tell whether the
+ *              parsing still fits the layout of the site. These need
+ *              the network, hence only run with the integration tag:
+ *               go test -tags integration ./imdb
+ *-----------------------------------------------------------------
+ */
+package imdb
+
+import (
+    "time"
+    "context"
+    "testing"
+)
+
+func TestLiveCharts (t *testing.T) {
+
+    o := DefaultOptions()
+    o.Details = false
+    if err := Configure (o); err != nil {
+        t.Fatal (err)
+    }
+    defer Configure (DefaultOptions())
+
+    for _, name := range []string {"indian", "tamil", "telugu"} {
+        t.Run (name, func (t *testing.T) {
+            chartUrl, err := ChartURL (name)
+            if err != nil {
+                t.Fatal (err)
+            }
+            ctx, cancel := context.WithTimeout (context.Background(), time.Minute)
+            defer cancel()
+
+            chart, err := Crawl (ctx, chartUrl, 1)
+            if err != nil {
+                t.Fatal (err)
+            }
+            if len (chart.Movies) == 0 {
+                t.Fatal ("no movies")
+            }
+            mov := chart.Movies[0]
+            if mov.Title == "" {
+                t.Errorf ("first movie has no title: %+v", mov)
+            }
+            if mov.Rating < 0 || mov.Rating > 10 {
+                t.Errorf ("first movie %q has the rating %v, not in [0,10]", mov.Title, mov.Rating)
+            }
+        })
+    }
+}
@@ -0,0 +1,62 @@
+/*
+ *-----------------------------------------------------------------
+ * IMDb Chart Fetcher - Interstitials
+ *-----------------------------------------------------------------
+ * Description: Detection of the interstitial pages IMDb serves in
+ *              place of the page asked for, i.e. the cookie consent
+ *              page (in some regions) & the age verification page
+ *              (for some titles). They come with 200 OK but have
+ *              none of the content, so parsing them gives movies
+ *              with all the fields empty.
+ *              Such a page fails the fetch with an error saying what
+ *              to do: accept the page in a browser & give its cookies
+ *              via -cookie. The consent cannot be given automatically
+ *              as the cookies differ across regions & over time.
+ *-----------------------------------------------------------------
+ */
+package imdb
+
+import (
+    "fmt"
+    "regexp"
+)
+
+// kinds of interstitials
+const (
+    interstitial_Consent = `consent`
+    interstitial_AgeGate = `age verification`
+)
+
+// distinctive markup of the interstitial pages, i.e. their title & the form of the
+// consent, in the order they are checked. A mere link to e.g. consent.imdb.com, as in
+// the footer of the regular pages, is not one.
+var interstitialMarkups = []struct {
+    kind   string
+    markup *regexp.Regexp
+}{
+    {interstitial_Consent, regexp.MustCompile (`(?i)<title>\s*Before you continue|<form\b[^>]*\baction="https?://consent\.imdb\.com/|\bid="consent-page"`)},
+    {interstitial_AgeGate, regexp.MustCompile (`(?i)<title>\s*Age verification|\bid="age-gate"`)},
+}
+
+// interstitialError is the error for an interstitial page served by IMDb instead of
+// the one at the URL.
+type interstitialError struct {
+    kind string
+    url  string
+}
+
+func (e interstitialError) Error () string {
+    return fmt.Sprintf ("IMDb served a %s page instead of %s. Open it in a browser, accept it & pass the cookies of the browser via -cookie", e.kind, e.url)
+}
+
+// detectInterstitial provides the kind of the interstitial if the page is one, or
+// empty otherwise.
+func detectInterstitial (body string) string {
+
+    for _, page := range interstitialMarkups {
+        if page.markup.MatchString (body) {
+            return page.kind
+        }
+    }
+    return ""
+}
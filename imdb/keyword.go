@@ -0,0 +1,150 @@
+/*
+ *-----------------------------------------------------------------
+ * IMDb Chart Fetcher - Keyword Search
+ *-----------------------------------------------------------------
+ * Description: Layout of the IMDb keyword search results, e.g.
+ *              https://www.imdb.com/search/keyword?keywords=based-on-true-story
+ *              The results are not a table but a list of items, each
+ *              item having a header with the title link & the year,
+ *              followed by the rating bar & the number of votes.
+ *              The title, release year, rating & number of votes are
+ *              parsed from the item while the summary, duration &
+ *              genre are crawled from the detail page, same as for
+ *              the charts.
+ *-----------------------------------------------------------------
+ */
+package imdb
+
+import (
+    "fmt"
+    "sync"
+    "regexp"
+    "context"
+    "strings"
+    "strconv"
+)
+
+// HTML element classes of the keyword search results used as selectors
+const (
+    kw_listClass   = `lister-list`
+    kw_itemClass   = `lister-item `
+    kw_headerClass = `lister-item-header`
+    kw_yearClass   = `lister-item-year`
+)
+
+// layout of the keyword search results, where each movie is an item of the list
+var keywordLayout = listLayout{keywordList, keywordItems, getKeywordTitleData, getKeywordRating}
+
+// selectors of the item of the keyword search results
+var (
+    kwTitleRegexp = regexp.MustCompile (`(?s)<a href="([^"?]*)[^"]*"\s*>(.*?)</a>`)
+    kwYearRegexp  = regexp.MustCompile (`\((\d{4}(?:\s*[–-]\s*(?:\d{4})?)?)`)
+    kwRateRegexp  = regexp.MustCompile (`name="ir" data-value="([\d.]+)"`)
+    kwVotesRegexp = regexp.MustCompile (`name="nv" data-value="(\d+)"`)
+)
+
+// keywordList extracts the list of the movies from the keyword search page. As the
+// items are nested div elements, everything from the start of the list is taken.
+func keywordList (page string) string {
+
+    listStrtIdx := strings.Index(page, `<div class="`+kw_listClass+`">`)
+    if listStrtIdx == -1 {
+        return ""
+    }
+    return page[listStrtIdx : ]
+}
+
+// keywordItems splits the list into the items of the movies, skipping the part
+// before the first item.
+func keywordItems (list string) []string {
+
+    recSlc := strings.Split(list, `<div class="`+kw_itemClass)
+    return recSlc[1:]
+}
+
+// getKeywordTitleData is triggered as a goroutine and it fetches & parses the data
+// from the item of the keyword search results. Like getTitleData, the title &
+// release year are parsed from the item & then the summary, genre & duration are
+// fetched from the detail page.
+func getKeywordTitleData (ctx context.Context, movieRec string, t *TitleData, errs *[]FieldError, wg *sync.WaitGroup) {
+
+    defer wg.Done()
+
+    // item header
+    // contains title, release year, and link to summary, duration & genre
+    hdrStrtIdx := strings.Index(movieRec, `<h3 class="`+kw_headerClass)
+    if hdrStrtIdx == -1 {
+        failField (errs, field_Title, "Could not find the title in the search result")
+        return
+    }
+    hdrEndIdx := strings.Index(movieRec[hdrStrtIdx : ], `</h3>`)
+    if hdrEndIdx == -1 {
+        failField (errs, field_Title, "Could not find the end of the header in the search result")
+        return
+    }
+    header := movieRec[hdrStrtIdx : hdrStrtIdx + hdrEndIdx]
+
+    // link to more info, without the query string & the title
+    lnkMatch := kwTitleRegexp.FindStringSubmatch(header)
+    if lnkMatch == nil {
+        failField (errs, field_Title, "Could not find the title in the search result")
+        return
+    }
+    moreInfoURL := imdb_url_Main + lnkMatch[1]
+    t.DetailURL = moreInfoURL
+    t.IMDbID = titleID (moreInfoURL)
+
+    // only title
+    title := cleanTitle (ctx, stripTags (lnkMatch[2]))
+    t.Title = title
+
+    // poster thumbnail, until the one of the detail page
+    if opts.IncludePosterURL {
+        t.PosterURL = rowPoster (movieRec)
+    }
+
+    // release year, the year may be preceded by a roman numeral like (I) (2019) & be a
+    // range for a TV series like (2019–2023)
+    yearAttr := `<span class="`+kw_yearClass
+    yearStrtIdx := strings.Index(header, yearAttr)
+    var yearMatch []string
+    if yearStrtIdx != -1 {
+        yearMatch = kwYearRegexp.FindStringSubmatch(header[yearStrtIdx : ])
+    }
+    var err error
+    if yearMatch == nil {
+        err = fmt.Errorf ("No year in the header")
+    } else {
+        t.ReleaseYear, t.EndYear, err = parseYearRange (yearMatch[1])
+    }
+    if err != nil {
+        failField (errs, field_ReleaseYear, "Could not obtain release year for", title, err)
+    }
+
+    // fetch summary, duration & genre once the item is parsed
+    // not needed for the lite output
+    if crawlInline() {
+        setDetails (t, fetchMoreInfo (ctx, moreInfoURL), errs)
+    }
+}
+
+// getKeywordRating handles the extraction of rating & the number of votes from the
+// item of the keyword search results. Titles that are yet to be released have none.
+func getKeywordRating (ctx context.Context, movieRec string, rate *float64, votes *uint64, errs *[]FieldError, wg *sync.WaitGroup) {
+
+    defer wg.Done()
+
+    // rating e.g. <div class="inline-block ratings-imdb-rating" name="ir" data-value="8.6">
+    if rateMatch := kwRateRegexp.FindStringSubmatch(movieRec); rateMatch != nil {
+        *rate, _ = strconv.ParseFloat(rateMatch[1], 64)
+    } else {
+        failField (errs, field_Rating, "Could not obtain rating")
+    }
+
+    // number of votes e.g. <span name="nv" data-value="20000">20,000</span>
+    if voteMatch := kwVotesRegexp.FindStringSubmatch(movieRec); voteMatch != nil {
+        *votes, _ = strconv.ParseUint(voteMatch[1], 10, 64)
+    } else {
+        failField (errs, field_Votes, "Could not obtain number of votes")
+    }
+}
@@ -0,0 +1,108 @@
+/*
+ *-----------------------------------------------------------------
+ * IMDb Chart Fetcher - Tests of the keyword search
+ *-----------------------------------------------------------------
+ */
+package imdb
+
+import (
+    "sync"
+    "context"
+    "reflect"
+    "testing"
+)
+
+func TestCrawlKeyword (t *testing.T) {
+
+    keywordUrl := search_url_Keyword + "?keywords=based-on-true-story"
+    pages := detailPages (t)
+    pages[keywordUrl] = fixture (t, "keyword.html")
+    configureTest (t, DefaultOptions(), pages)
+
+    chart, err := Crawl (context.Background(), keywordUrl, 0)
+    if err != nil {
+        t.Fatal (err)
+    }
+
+    type movie struct {
+        id     string
+        title  string
+        year   uint64
+        url    string
+        rating float64
+        votes  uint64
+        genre  string
+    }
+    tests := []struct {
+        want movie
+        errs []string
+    }{
+        {movie{"tt0000001", "Nayakan", 1987, testURL_Detail1, 8.6, 20000, "Crime, Drama"}, nil},
+        {movie{"tt0000002", "Anbe Sivam", 2003, testURL_Detail2, 0, 0, "Crime, Drama"}, []string {field_Rating, field_Votes}},
+    }
+    if len (chart.Movies) != len (tests) {
+        t.Fatalf ("%d movies, want %d", len (chart.Movies), len (tests))
+    }
+    for i, mov := range chart.Movies {
+        got := movie{mov.IMDbID, mov.Title, mov.ReleaseYear, mov.DetailURL, mov.Rating, mov.Votes, mov.Genre}
+        if got != tests[i].want {
+            t.Errorf ("movie %d is %+v, want %+v", i, got, tests[i].want)
+        }
+        if !reflect.DeepEqual (mov.Errors, tests[i].errs) {
+            t.Errorf ("movie %d has errors %v, want %v", i, mov.Errors, tests[i].errs)
+        }
+        for _, e := range mov.FieldErrors {
+            if e.Message == "" {
+                t.Errorf ("movie %d has no message for %s", i, e.Field)
+            }
+        }
+    }
+}
+
+func TestKeywordTitleData (t *testing.T) {
+
+    o := DefaultOptions()
+    o.Details = false
+    configureTest (t, o, nil)
+
+    tests := []struct {
+        name string
+        item string
+        want TitleData
+        errs []string
+    }{
+        {
+            name: "title & year",
+            item: `mode-detail"><h3 class="lister-item-header"><a href="/title/tt0000001/?ref_=kw_li_tt">Nayakan</a> <span class="lister-item-year text-muted unbold">(I) (1987)</span></h3>`,
+            want: TitleData{IMDbID: "tt0000001", Title: "Nayakan", ReleaseYear: 1987, DetailURL: testURL_Detail1},
+        },
+        {
+            name: "header not closed",
+            item: `mode-detail"><h3 class="lister-item-header"><a href="/title/tt0000001/">Nayakan</a> <span class="lister-item-year">(1987)</span>`,
+            errs: []string {field_Title},
+        },
+        {
+            name: "no header",
+            item: `mode-detail"><a href="/title/tt0000001/">Nayakan</a>`,
+            errs: []string {field_Title},
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run (tt.name, func (t *testing.T) {
+            var (
+                got  TitleData
+                errs []FieldError
+                wg   sync.WaitGroup
+            )
+            wg.Add(1)
+            getKeywordTitleData (context.Background(), tt.item, &got, &errs, &wg)
+            if !reflect.DeepEqual (got, tt.want) {
+                t.Errorf ("title data %+v, want %+v", got, tt.want)
+            }
+            if !reflect.DeepEqual (fieldNames (errs), tt.errs) {
+                t.Errorf ("errors %v, want %v", errs, tt.errs)
+            }
+        })
+    }
+}
@@ -0,0 +1,110 @@
+/*
+ *-----------------------------------------------------------------
+ * IMDb Chart Fetcher - Logging
+ *-----------------------------------------------------------------
+ * Description: Non-fatal issues like a field that could not be
+ *              fetched or parsed are reported as warnings.
+ *              The log is leveled (LogLevel of the Options,
+ *              -log-level), each level showing the ones before it:
+ *               error  nothing but the errors ending the run
+ *               warn   the warnings (FAILURE, ALARM) as well
+ *               info   the progress of the crawl, per movie
+ *               debug  the boundaries of the fields as extracted
+ *              By default the log goes to stderr. When
+ *              a warnings sink is given (Warnings of the Options,
+ *              -warnings-out) they are written to it as JSON records
+ *              instead, one per line:
+ *               {"time":"...","level":"FAILURE","message":"..."}
+ *              so that stdout carries only the result data & the
+ *              diagnostics can be consumed separately. Being asked
+ *              for explicitly, the sink receives the warnings
+ *              whatever the level.
+ *              The sink can be a file or an already open fd, e.g.
+ *              -warnings-out=/dev/fd/3
+ *              The FAILURE warnings of a crawl, i.e. of a field not
+ *              fetched or parsed, are counted in its Failures.
+ *-----------------------------------------------------------------
+ */
+package imdb
+
+import (
+    "fmt"
+    "log"
+    "time"
+    "strings"
+    "sync/atomic"
+    "encoding/json"
+)
+
+// Structure of a warning as written to the warnings sink
+type warningRecord struct {
+    Time    string `json:"time"`
+    Level   string `json:"level"`
+    Message string `json:"message"`
+}
+
+// levels of the log, in the order of verbosity
+const (
+    level_Error = iota
+    level_Warn
+    level_Info
+    level_Debug
+)
+var logLevels = map[string]int {
+    "error": level_Error,
+    "warn":  level_Warn,
+    "info":  level_Info,
+    "debug": level_Debug,
+}
+
+// level of the log in effect, by LogLevel
+var logLevel = level_Error
+
+// the warnings sink, nil when the warnings are logged to stderr.
+// log.Logger serializes the writes coming from the concurrent goroutines.
+var warnSink *log.Logger
+
+// number of the FAILURE warnings so far, for the failures of a crawl
+var failureCount int64
+
+// warn reports a non-fatal issue of the given level (FAILURE, ALARM). The operands
+// form the message as they would for log.Println.
+func warn (level string, v ...interface{}) {
+    if level == "FAILURE" {
+        atomic.AddInt64 (&failureCount, 1)
+    }
+    logAt (level_Warn, level, v...)
+}
+
+// info reports the progress of the crawl.
+func info (v ...interface{}) {
+    logAt (level_Info, "INFO", v...)
+}
+
+// debug reports the details of the parsing, e.g. the boundaries of a field.
+func debug (v ...interface{}) {
+    logAt (level_Debug, "DEBUG", v...)
+}
+
+// logAt logs the message under the given label if the log level in effect shows
+// the level of the message. The warnings go to the sink anyway.
+func logAt (lvl int, level string, v ...interface{}) {
+
+    if lvl > logLevel && !(warnSink != nil && lvl == level_Warn) {
+        return
+    }
+
+    msg := strings.TrimSuffix (fmt.Sprintln (v...), "\n")
+
+    if warnSink == nil {
+        log.Println (level + ":", msg)
+        return
+    }
+
+    rec, err := json.Marshal (warningRecord{time.Now().Format(time.RFC3339), level, msg})
+    if err != nil {
+        log.Println (level + ":", msg)
+        return
+    }
+    warnSink.Println (string(rec))
+}
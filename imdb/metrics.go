@@ -0,0 +1,111 @@
+/*
+ *-----------------------------------------------------------------
+ * IMDb Chart Fetcher - Metrics
+ *-----------------------------------------------------------------
+ * Description: Runtime counters published via the standard expvar
+ *              package, so that the existing monitoring can scrape
+ *              them as JSON from /debug/vars:
+ *               - crawls             : charts/lists crawled
+ *               - movies_fetched     : movies populated
+ *               - fetch_errors       : failed fetches from IMDb
+ *               - requests           : requests made to IMDb
+ *               - translation_retries: translations retried on 429,
+ *                                      the requests to IMDb are not
+ *               - cache_hits         : detail pages served by the cache
+ *               - cache_misses       : detail pages not in the cache
+ *                                      (or stale), hence fetched
+ *               - cache_hit_ratio    : hits of all the lookups of the
+ *                                      cache
+ *              expvar registers /debug/vars on the default HTTP mux,
+ *              which the program serves on the address given by
+ *              -debug-addr while it runs. The counters are atomic, so
+ *              they are incremented directly from the goroutines.
+ *              ReadStats provides them to the caller, e.g. for -stats.
+ *
+ *              Further metrics are given to the crawl observer. The
+ *              default one ignores them, while building the program
+ *              with the tag prometheus installs the Prometheus
+ *              observer. See ../metrics_prometheus.go
+ *-----------------------------------------------------------------
+ */
+package imdb
+
+import (
+    "time"
+    "expvar"
+)
+
+// runtime counters
+var (
+    crawlCount         = expvar.NewInt ("crawls")
+    moviesFetched      = expvar.NewInt ("movies_fetched")
+    fetchErrors        = expvar.NewInt ("fetch_errors")
+    requestCount       = expvar.NewInt ("requests")
+    translationRetries = expvar.NewInt ("translation_retries")
+    cacheHits          = expvar.NewInt ("cache_hits")
+    cacheMisses        = expvar.NewInt ("cache_misses")
+)
+
+func init () {
+    expvar.Publish ("cache_hit_ratio", expvar.Func(func () interface{} {
+        return ReadStats().CacheHitRatio()
+    }))
+}
+
+// Structure to maintain the values of the runtime counters at a point in time, for
+// the callers to report them, e.g. at the end of the run.
+type Stats struct {
+    Requests           int64
+    TranslationRetries int64
+    FetchErrors        int64
+    CacheHits          int64
+    CacheMisses        int64
+}
+
+// ReadStats provides the runtime counters as of now, i.e. of all the crawls so far.
+func ReadStats () Stats {
+    return Stats{requestCount.Value(), translationRetries.Value(), fetchErrors.Value(), cacheHits.Value(), cacheMisses.Value()}
+}
+
+// CacheHitRatio provides the share of the lookups of the cache served by it, 0 if
+// there were none (e.g. without CacheDir).
+func (s Stats) CacheHitRatio () float64 {
+
+    if s.CacheHits + s.CacheMisses == 0 {
+        return 0
+    }
+    return float64(s.CacheHits) / float64(s.CacheHits + s.CacheMisses)
+}
+
+// types of fetch errors as given to the crawl observer
+const (
+    fetchErr_Request = `request`
+    fetchErr_Status  = `status`
+    fetchErr_Body    = `body`
+    fetchErr_Gate    = `interstitial`
+)
+
+// CrawlObserver receives the measurements of the fetches & crawls as they happen.
+type CrawlObserver interface {
+    // Fetched is called after every fetch from IMDb with its latency & the type
+    // of the error, empty if the fetch succeeded
+    Fetched (d time.Duration, errType string)
+    // Crawled is called after a chart is crawled with its duration & the number
+    // of movies fetched
+    Crawled (d time.Duration, movies int)
+}
+
+// noObserver is the default crawl observer which ignores the measurements.
+type noObserver struct{}
+
+func (noObserver) Fetched (d time.Duration, errType string) {}
+func (noObserver) Crawled (d time.Duration, movies int) {}
+
+// the crawl observer in use
+var observer CrawlObserver = noObserver{}
+
+// SetObserver installs the crawl observer, e.g. one recording the measurements as
+// the metrics of a monitoring system.
+func SetObserver (o CrawlObserver) {
+    observer = o
+}
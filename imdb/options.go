@@ -0,0 +1,197 @@
+/*
+ *-----------------------------------------------------------------
+ * IMDb Chart Fetcher - Options
+ *-----------------------------------------------------------------
+ * Description: Options of the crawl, i.e. what is fetched for the
+ *              movies, how they are filtered & sorted & how IMDb is
+ *              requested. Each of them is the counterpart of one of
+ *              the command-line options, e.g. MinRating of -min-rating.
+ *              DefaultOptions provides the defaults of the command-
+ *              line program, which are in effect until Configure is
+ *              given others.
+ *-----------------------------------------------------------------
+ */
+package imdb
+
+import (
+    "io"
+    "os"
+    "fmt"
+    "log"
+    "time"
+    "math/rand"
+    "crypto/x509"
+)
+
+// Structure to maintain the options of the crawl. The zero value of a field leaves
+// the respective feature off.
+type Options struct {
+    // what is crawled for the movies
+    Details              bool                // crawl the detail pages for the summary, duration, genre etc.
+    LazyDetails          bool                // crawl the detail pages only for the movies passing the chart filters, implied by MinRating
+    Storyline            bool                // fetch the storyline from the plot summary page as well
+    MaxStorylineRequests int                 // cap of the storyline requests of a crawl, 0 for no limit
+    TVEpisodes           bool                // crawl the episodes of the TV series
+    TranslateTo          string              // language to translate the summary to, via TranslateURL
+    TranslateURL         string              // LibreTranslate compatible endpoint
+    PrettyDuration       bool                // present the duration as e.g. 2h 6m
+    CollapseGenres       bool                // map the genres to the buckets as well
+    GenreBuckets         map[string]string   // mapping of the genres to the buckets, the built-in one if nil
+    NormalizeTitle       string              // strip the rank from the titles: on, off or auto
+    IncludePosterURL     bool                // the URL of the poster image, preferably the one of the detail page
+    MaxSummaryLength     int                 // characters the summary & the storyline are truncated to, 0 for no limit
+    ExtractSummary       string              // regexps overriding the built-in parsing of the fields
+    ExtractDuration      string
+    ExtractGenre         string
+
+    // which movies & in what order
+    RankFrom             int                 // chart rank of the first movie
+    RankTo               int                 // chart rank of the last movie, 0 for no limit
+    MinRating            float64             // the count is then of the first movies rated at least this & passing the other filters
+    MinVotes             uint64
+    KeepUnknownVotes     bool                // keep the movies whose number of votes is unknown, with MinVotes
+    Baseline             map[string]bool     // title IDs to leave out, see LoadBaseline
+    TitleTypes           []string            // title types to keep, e.g. Movie, TVSeries
+    SortBy               string              // rank, rating, votes, year or title, prefixed with - (or suffixed with -desc) for descending
+    SortStable           bool
+
+    // how IMDb is requested
+    UserAgent            string
+    UserAgents           []string            // picked at random for each request, see LoadUserAgents
+    Cookie               string
+    Proxy                string              // http(s) or socks5 URL of the proxy, that of the environment if empty
+    RootCAs              *x509.CertPool      // CAs to verify IMDb (or the proxy) against, those of the system if nil, see LoadCACerts
+    InsecureTLS          bool                // skip the verification of the certificates, dangerous, only for tests
+    Timeout              time.Duration
+    PoolIdleTimeout      time.Duration
+    MaxRedirects         int                 // negative for the default of 10
+    Concurrency          int                 // maximum number of requests in flight, 0 for no limit
+    DetailConcurrency    int                 // maximum number of detail page & storyline requests in flight, within Concurrency, 0 for no limit of their own
+    RequestDelay         time.Duration       // minimum interval between the starts of the requests, 0 for none
+    AdaptiveMax          int                 // see adaptive.go
+    AdaptiveLatency      time.Duration
+    CacheDir             string              // directory caching the detail pages across the crawls, see cache.go
+    CacheTTL             time.Duration       // age beyond which a cached page is fetched afresh, 0 for no limit
+    Fetcher              Fetcher             // serves the pages instead of IMDb if given, e.g. LoadArchive
+    RespectRobots        bool                // request only the pages allowed by robots.txt, see robots.go
+
+    Warnings             io.Writer           // receives the warnings as JSON records instead of stderr, see logging.go
+    LogLevel             string              // error, warn, info or debug, error if empty
+}
+
+// options in effect
+var opts = DefaultOptions()
+
+// DefaultOptions provides the options having the defaults of the command-line
+// program, i.e. the movies of the chart with their details, in the chart order.
+func DefaultOptions () Options {
+    return Options{
+        Details:          true,
+        NormalizeTitle:   "auto",
+        RankFrom:         1,
+        KeepUnknownVotes: true,
+        SortStable:       true,
+        UserAgent:        default_UserAgent,
+        Timeout:          15 * time.Second,
+        PoolIdleTimeout:  90 * time.Second,
+        MaxRedirects:     -1,
+        Concurrency:      8,
+        AdaptiveLatency:  2 * time.Second,
+        RespectRobots:    true,
+        LogLevel:         "error",
+    }
+}
+
+// Configure validates the options & puts them in effect for the crawls that follow.
+// The options in effect stay as they are if the given ones are invalid.
+func Configure (o Options) error {
+
+    if o.RankFrom < 1 || (o.RankTo != 0 && o.RankTo < o.RankFrom) {
+        return fmt.Errorf ("Invalid rank window. The first rank should be at least 1 & not beyond the last")
+    }
+    if field, _ := parseSortKey (o.SortBy); o.SortBy != "" && sortKeys[field] == nil {
+        return fmt.Errorf ("Invalid sort key %q. Should be one of rank, rating, votes, year or title", o.SortBy)
+    }
+    if o.NormalizeTitle != "on" && o.NormalizeTitle != "off" && o.NormalizeTitle != "auto" {
+        return fmt.Errorf ("Invalid title normalization %q. Should be on, off or auto", o.NormalizeTitle)
+    }
+    if (o.TranslateTo == "") != (o.TranslateURL == "") {
+        return fmt.Errorf ("The language & the endpoint of the translation go together")
+    }
+    if o.MaxSummaryLength < 0 {
+        return fmt.Errorf ("Invalid summary length %d. Should not be negative", o.MaxSummaryLength)
+    }
+    if o.Concurrency < 0 {
+        return fmt.Errorf ("Invalid concurrency %d. Should not be negative", o.Concurrency)
+    }
+    if o.DetailConcurrency < 0 {
+        return fmt.Errorf ("Invalid detail concurrency %d. Should not be negative", o.DetailConcurrency)
+    }
+    if _, err := proxyURL (o.Proxy); err != nil {
+        return fmt.Errorf ("Invalid proxy. %v", err)
+    }
+    if o.CacheTTL < 0 {
+        return fmt.Errorf ("Invalid cache TTL %v. Should not be negative", o.CacheTTL)
+    }
+    if o.CacheDir != "" {
+        if err := os.MkdirAll (o.CacheDir, 0755); err != nil {
+            return fmt.Errorf ("Invalid cache directory. %v", err)
+        }
+    }
+    level, ok := logLevels[o.LogLevel]
+    if o.LogLevel != "" && !ok {
+        return fmt.Errorf ("Invalid log level %q. Should be error, warn, info or debug", o.LogLevel)
+    }
+
+    // user supplied extraction of the fields, validated before any fetch
+    overrides, err := compileFieldOverrides (map[string]string {
+        field_Summary:  o.ExtractSummary,
+        field_Duration: o.ExtractDuration,
+        field_Genre:    o.ExtractGenre,
+    })
+    if err != nil {
+        return err
+    }
+
+    opts = o
+    fieldOverrides = overrides
+
+    genreBuckets = defaultGenreBuckets
+    if o.GenreBuckets != nil {
+        genreBuckets = o.GenreBuckets
+    }
+
+    // the detail pages bounded of their own, whatever the fetcher
+    detailSlots = nil
+    if o.DetailConcurrency > 0 {
+        detailSlots = make (chan struct{}, o.DetailConcurrency)
+    }
+
+    logLevel = level
+    warnSink = nil
+    if o.Warnings != nil {
+        warnSink = log.New (o.Warnings, "", 0)
+    }
+
+    // the pages from IMDb, rotating the User-Agents if given, bounding the requests
+    // in flight, adapting the rate if asked for & honoring robots.txt, unless served
+    // from elsewhere
+    fetcher = o.Fetcher
+    if fetcher == nil {
+        httpF := httpFetcher{client: newHTTPClient(), userAgents: o.UserAgents}
+        if o.Concurrency > 0 {
+            httpF.slots = make (chan struct{}, o.Concurrency)
+        }
+        fetcher = httpF
+        if len (o.UserAgents) > 0 {
+            rand.Seed (time.Now().UnixNano())
+        }
+        if o.AdaptiveMax > 0 {
+            fetcher = newAdaptiveFetcher (fetcher, o.AdaptiveMax, o.AdaptiveLatency)
+        }
+        if o.RespectRobots {
+            fetcher = newRobotsFetcher (fetcher)
+        }
+    }
+    return nil
+}
@@ -0,0 +1,102 @@
+/*
+ *-----------------------------------------------------------------
+ * IMDb Chart Fetcher - Pagination
+ *-----------------------------------------------------------------
+ * Description: The lists paginated by IMDb (e.g. the keyword search
+ *              results, 50 movies a page) are followed page by page
+ *              via their "next page" link:
+ *                <a href="...&page=2" class="lister-page-next next-page">
+ *              or
+ *                <link rel="next" href="...&page=2">
+ *              accumulating the rows till there are as many as the
+ *              count asks for (all of them for 0, or when filtered by
+ *              rating) or there are no more pages. The pages are
+ *              fetched one after the other, as the requests of the
+ *              crawl, i.e. under -concurrency & -delay.
+ *-----------------------------------------------------------------
+ */
+package imdb
+
+import (
+    "html"
+    "regexp"
+    "context"
+    "net/url"
+)
+
+// the "next page" link & its target
+var (
+    nextPageRegexp = regexp.MustCompile (`<(?:a|link)\b[^>]*\b(?:rel="next"|class="[^"]*\bnext-page\b[^"]*")[^>]*>`)
+    hrefRegexp     = regexp.MustCompile (`\bhref="([^"]*)"`)
+)
+
+// nextPageURL provides the URL of the page following the given one of the list, or
+// empty if it is the last page.
+func nextPageURL (page string, pageUrl string) string {
+
+    lnk := nextPageRegexp.FindString(page)
+    if lnk == "" {
+        return ""
+    }
+    href := hrefRegexp.FindStringSubmatch(lnk)
+    if href == nil {
+        return ""
+    }
+
+    // relative to the page, e.g. ?keywords=...&page=2
+    base, err := url.Parse (pageUrl)
+    if err != nil {
+        return ""
+    }
+    next, err := base.Parse (html.UnescapeString(href[1]))
+    if err != nil {
+        return ""
+    }
+    return next.String()
+}
+
+// rowsNeeded tells whether more rows than the given number are needed for the count
+// of movies, the count being of the ranks from RankFrom.
+func rowsNeeded (rows int, itemCount int) bool {
+
+    if opts.RankTo > 0 && rows >= opts.RankTo {
+        return false
+    }
+    if itemCount == 0 || opts.MinRating > 0 {
+        return true
+    }
+    return rows < opts.RankFrom - 1 + itemCount
+}
+
+// nextPagesRows fetches the pages following the given first page of the list as long
+// as more rows are needed, providing their rows. A page that cannot be fetched ends
+// the list there.
+func nextPagesRows (ctx context.Context, listFetcher Fetcher, layout listLayout, pageUrl string, page string, rows int, itemCount int) []string {
+
+    var recSlc []string
+    seen := map[string]bool {pageUrl: true}
+
+    for rowsNeeded (rows + len (recSlc), itemCount) {
+        next := nextPageURL (page, pageUrl)
+        if next == "" || seen[next] {
+            break
+        }
+        seen[next] = true
+
+        var err error
+        page, err = listFetcher.Get (ctx, next)
+        if err != nil {
+            warn ("FAILURE", "Could not fetch the next page of the list.", err)
+            break
+        }
+        info ("Fetched the page", next)
+        pageUrl = next
+
+        pageRows := layout.rows (layout.list (page))
+        if len (pageRows) == 0 {
+            break
+        }
+        recSlc = append (recSlc, pageRows...)
+    }
+    return recSlc
+}
@@ -0,0 +1,87 @@
+/*
+ *-----------------------------------------------------------------
+ * IMDb Chart Fetcher - Posters
+ *-----------------------------------------------------------------
+ * Description: URL of the poster image of the movies, asked for by
+ *              -include-poster-url. The poster is on the rows of the
+ *              list as a thumbnail & on the detail page in a higher
+ *              resolution, which is preferred, i.e. the image of the
+ *              structured data (JSON-LD) or else the <img> of the
+ *              poster element. The thumbnail is kept when the detail
+ *              page is not crawled or has no poster.
+ *              The images loaded lazily carry the real URL in the
+ *              loadlate or data-src attribute, src being a placeholder
+ *              until then, hence those are taken first.
+ *-----------------------------------------------------------------
+ */
+package imdb
+
+import (
+    "html"
+    "regexp"
+    "encoding/json"
+)
+
+// the images, their source attributes in order of preference & the poster element
+// of the detail page
+var (
+    imgRegexp    = regexp.MustCompile (`<img\b[^>]*>`)
+    srcRegexps   = []*regexp.Regexp {
+        regexp.MustCompile (`\bloadlate="([^"]+)"`),
+        regexp.MustCompile (`\bdata-src="([^"]+)"`),
+        regexp.MustCompile (`\bsrc="([^"]+)"`),
+    }
+    posterRegexp = regexp.MustCompile (`class="[^"]*\b(?:ipc-)?poster\b`)
+)
+
+// ldImage is the image of the structured data, given either as its URL or as an
+// ImageObject.
+type ldImage string
+
+func (i *ldImage) UnmarshalJSON (data []byte) error {
+
+    var imgUrl string
+    if err := json.Unmarshal (data, &imgUrl); err != nil {
+        var obj struct {
+            URL string `json:"url"`
+        }
+        if err := json.Unmarshal (data, &obj); err != nil {
+            return err
+        }
+        imgUrl = obj.URL
+    }
+    *i = ldImage(html.UnescapeString(imgUrl))
+    return nil
+}
+
+// imgSource provides the URL of the image of the <img> element, the one to be lazily
+// loaded if any.
+func imgSource (img string) string {
+
+    for _, r := range srcRegexps {
+        if m := r.FindStringSubmatch(img); m != nil {
+            return html.UnescapeString(m[1])
+        }
+    }
+    return ""
+}
+
+// rowPoster provides the URL of the poster thumbnail of the row of the list, i.e. of
+// its first image, or empty if it has none.
+func rowPoster (movieRec string) string {
+    return imgSource (imgRegexp.FindString(movieRec))
+}
+
+// detailPoster provides the URL of the poster of the detail page, from the structured
+// data or else from the poster element, or empty if it has none.
+func detailPoster (respBody string, ld ldData) string {
+
+    if ld.Image != "" {
+        return string(ld.Image)
+    }
+    loc := posterRegexp.FindStringIndex(respBody)
+    if loc == nil {
+        return ""
+    }
+    return imgSource (imgRegexp.FindString(respBody[loc[1] : ]))
+}
@@ -0,0 +1,139 @@
+/*
+ *-----------------------------------------------------------------
+ * IMDb Chart Fetcher - Replay
+ *-----------------------------------------------------------------
+ * Description: Serves the pages from an archive of previously saved
+ *              responses instead of the IMDb website, so that a run
+ *              is fully deterministic & needs no network at all.
+ *
+ *              The archive can either be a zip or a tar file. Each
+ *              entry holds the raw response body of one page & is
+ *              named by the query-escaped URL of that page, e.g.
+ *              https%3A%2F%2Fwww.imdb.com%2Ftitle%2Ftt0093603%2F
+ *              (see archiveEntryName).
+ *
+ *              A page that is not present in the archive fails the
+ *              fetch just as a network error would.
+ *
+ *              MapFetcher serves the pages given in memory the same
+ *              way, e.g. the fixtures of a test of the crawl.
+ *-----------------------------------------------------------------
+ */
+package imdb
+
+import (
+    "io"
+    "os"
+    "fmt"
+    "context"
+    "net/url"
+    "strings"
+    "io/ioutil"
+    "archive/tar"
+    "archive/zip"
+)
+
+// archiveFetcher is the Fetcher which serves the pages from the saved responses,
+// keyed by the archive entry name of the URL.
+type archiveFetcher map[string]string
+
+// archiveEntryName provides the name of the archive entry holding the response
+// for the given URL.
+func archiveEntryName (pageUrl string) string {
+    return url.QueryEscape (pageUrl)
+}
+
+// Get obtains the saved response body of the given URL from the archive.
+func (a archiveFetcher) Get (ctx context.Context, pageUrl string) (string, error) {
+
+    if err := ctx.Err(); err != nil {
+        return "", err
+    }
+
+    body, ok := a[archiveEntryName(pageUrl)]
+    if !ok {
+        return "", fmt.Errorf ("%s not present in the replay archive", pageUrl)
+    }
+    return body, nil
+}
+
+// MapFetcher provides the Fetcher serving the given pages, keyed by their URL, as if
+// from an archive, for the Fetcher of the Options, e.g. the fixture HTML of a test.
+func MapFetcher (pages map[string]string) Fetcher {
+
+    archive := archiveFetcher{}
+    for pageUrl, body := range pages {
+        archive[archiveEntryName(pageUrl)] = body
+    }
+    return archive
+}
+
+// LoadArchive reads all the saved responses from the zip or tar archive at the
+// given path, the type being decided by the file extension. The Fetcher serves
+// them, for the Fetcher of the Options.
+func LoadArchive (path string) (Fetcher, error) {
+
+    if strings.HasSuffix (path, ".zip") {
+        return loadZipArchive (path)
+    }
+    return loadTarArchive (path)
+}
+
+// loadZipArchive reads all the saved responses from the zip archive.
+func loadZipArchive (path string) (archiveFetcher, error) {
+
+    zr, err := zip.OpenReader (path)
+    if err != nil {
+        return nil, err
+    }
+    defer zr.Close()
+
+    archive := archiveFetcher{}
+    for _, f := range zr.File {
+        if f.FileInfo().IsDir() {
+            continue
+        }
+        rc, err := f.Open()
+        if err != nil {
+            return nil, err
+        }
+        body, err := ioutil.ReadAll (rc)
+        rc.Close()
+        if err != nil {
+            return nil, err
+        }
+        archive[f.Name] = string(body)
+    }
+    return archive, nil
+}
+
+// loadTarArchive reads all the saved responses from the tar archive.
+func loadTarArchive (path string) (archiveFetcher, error) {
+
+    f, err := os.Open (path)
+    if err != nil {
+        return nil, err
+    }
+    defer f.Close()
+
+    archive := archiveFetcher{}
+    tr := tar.NewReader (f)
+    for {
+        hdr, err := tr.Next()
+        if err == io.EOF {
+            break
+        }
+        if err != nil {
+            return nil, err
+        }
+        if hdr.Typeflag != tar.TypeReg {
+            continue
+        }
+        body, err := ioutil.ReadAll (tr)
+        if err != nil {
+            return nil, err
+        }
+        archive[hdr.Name] = string(body)
+    }
+    return archive, nil
+}
@@ -0,0 +1,132 @@
+/*
+ *-----------------------------------------------------------------
+ * IMDb Chart Fetcher - Tests of the replay
+ *-----------------------------------------------------------------
+ */
+package imdb
+
+import (
+    "os"
+    "context"
+    "testing"
+    "archive/tar"
+    "archive/zip"
+    "path/filepath"
+)
+
+func TestMapFetcher (t *testing.T) {
+
+    withQuery := testURL_Detail2 + "?ref_=chttp_t_2"
+    f := MapFetcher (map[string]string {
+        testURL_Detail1: "one",
+        withQuery:       "two",
+    })
+
+    tests := []struct {
+        pageUrl string
+        want    string
+        ok      bool
+    }{
+        {testURL_Detail1, "one", true},
+        {withQuery, "two", true},
+        // served by the exact URL, as a fetch would be
+        {testURL_Detail2, "", false},
+        {testURL_Detail1 + "?ref_=chttp_t_1", "", false},
+        {testURL_Detail3, "", false},
+    }
+
+    for _, tt := range tests {
+        body, err := f.Get (context.Background(), tt.pageUrl)
+        if body != tt.want || (err == nil) != tt.ok {
+            t.Errorf ("Get(%q) = %q, %v, want %q", tt.pageUrl, body, err, tt.want)
+        }
+    }
+
+    ctx, cancel := context.WithCancel (context.Background())
+    cancel()
+    if _, err := f.Get (ctx, testURL_Detail1); err != context.Canceled {
+        t.Errorf ("error %v, want %v", err, context.Canceled)
+    }
+}
+
+// the crawl of the chart & its detail pages, none of them fetched from the network
+func TestMapFetcherCrawl (t *testing.T) {
+
+    pages := detailPages (t)
+    pages[chart_url_Tamil] = fixture (t, "chart.html")
+    configureTest (t, DefaultOptions(), pages)
+
+    chart, err := Crawl (context.Background(), chart_url_Tamil, 2)
+    if err != nil {
+        t.Fatal (err)
+    }
+    for i, mov := range chart.Movies {
+        if mov.Genre != "Crime, Drama" || len (mov.Errors) != 0 {
+            t.Errorf ("movie %d has the genre %q & errors %v", i, mov.Genre, mov.Errors)
+        }
+    }
+}
+
+func TestLoadArchive (t *testing.T) {
+
+    pages := map[string]string {
+        testURL_Detail1: fixture (t, "detail1.html"),
+        chart_url_Tamil: fixture (t, "chart.html"),
+    }
+    dir := t.TempDir()
+
+    zipPath := filepath.Join (dir, "pages.zip")
+    zf, err := os.Create (zipPath)
+    if err != nil {
+        t.Fatal (err)
+    }
+    zw := zip.NewWriter (zf)
+    for pageUrl, body := range pages {
+        w, err := zw.Create (archiveEntryName (pageUrl))
+        if err != nil {
+            t.Fatal (err)
+        }
+        w.Write ([]byte(body))
+    }
+    if err := zw.Close(); err != nil {
+        t.Fatal (err)
+    }
+    zf.Close()
+
+    tarPath := filepath.Join (dir, "pages.tar")
+    tf, err := os.Create (tarPath)
+    if err != nil {
+        t.Fatal (err)
+    }
+    tw := tar.NewWriter (tf)
+    for pageUrl, body := range pages {
+        hdr := &tar.Header{Name: archiveEntryName (pageUrl), Mode: 0644, Size: int64(len (body)), Typeflag: tar.TypeReg}
+        if err := tw.WriteHeader (hdr); err != nil {
+            t.Fatal (err)
+        }
+        tw.Write ([]byte(body))
+    }
+    if err := tw.Close(); err != nil {
+        t.Fatal (err)
+    }
+    tf.Close()
+
+    for _, path := range []string {zipPath, tarPath} {
+        f, err := LoadArchive (path)
+        if err != nil {
+            t.Fatalf ("%s: %v", path, err)
+        }
+        for pageUrl, want := range pages {
+            if body, err := f.Get (context.Background(), pageUrl); body != want || err != nil {
+                t.Errorf ("%s: Get(%q) = %d bytes, %v", path, pageUrl, len (body), err)
+            }
+        }
+        if _, err := f.Get (context.Background(), testURL_Detail2); err == nil {
+            t.Errorf ("%s: no error for a page not in the archive", path)
+        }
+    }
+
+    if _, err := LoadArchive (filepath.Join (dir, "missing.zip")); err == nil {
+        t.Error ("no error for a missing archive")
+    }
+}
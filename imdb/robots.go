@@ -0,0 +1,173 @@
+/*
+ *-----------------------------------------------------------------
+ * IMDb Chart Fetcher - Robots
+ *-----------------------------------------------------------------
+ * Description: Honors the robots.txt of IMDb, i.e. the pages it
+ *              disallows are not requested. The robots.txt of a host
+ *              is fetched once, before the first request to the host
+ *              (i.e. at the start of the crawl, along with the chart)
+ *              & its rules for all the robots (User-agent: *) are
+ *              applied to every request that follows:
+ *               - the longest matching Allow / Disallow rule wins,
+ *                 Allow on a tie
+ *               - * in a rule matches any characters & a trailing $
+ *                 anchors the rule at the end of the URL
+ *              A robots.txt that cannot be fetched (e.g. 404) allows
+ *              everything. A disallowed chart fails the crawl, while
+ *              a disallowed detail page leaves the details empty.
+ *              -ignore-robots turns it off.
+ *-----------------------------------------------------------------
+ */
+package imdb
+
+import (
+    "fmt"
+    "sync"
+    "regexp"
+    "context"
+    "strings"
+    "net/url"
+)
+
+// robotsRule is an Allow or Disallow rule of robots.txt.
+type robotsRule struct {
+    allow   bool
+    length  int
+    pattern *regexp.Regexp
+}
+
+// robotsRules are the rules of a robots.txt applying to all the robots.
+type robotsRules []robotsRule
+
+// robotsError is the error for a URL disallowed by the robots.txt of its host.
+type robotsError struct {
+    url string
+}
+
+func (e robotsError) Error () string {
+    return fmt.Sprintf ("%s is disallowed by robots.txt", e.url)
+}
+
+// robotsFetcher is the Fetcher which requests only the URLs allowed by the robots.txt
+// of their host via the wrapped Fetcher.
+type robotsFetcher struct {
+    Fetcher
+
+    mu    sync.Mutex
+    hosts map[string]*robotsHost
+}
+
+// robotsHost holds the rules of a host, loaded once.
+type robotsHost struct {
+    once  sync.Once
+    rules robotsRules
+}
+
+// newRobotsFetcher wraps the Fetcher to honor robots.txt.
+func newRobotsFetcher (f Fetcher) *robotsFetcher {
+    return &robotsFetcher{Fetcher: f, hosts: map[string]*robotsHost{}}
+}
+
+// Get obtains the page via the wrapped Fetcher if robots.txt allows it.
+func (r *robotsFetcher) Get (ctx context.Context, pageUrl string) (string, error) {
+
+    u, err := url.Parse (pageUrl)
+    if err != nil || u.Host == "" {
+        return r.Fetcher.Get (ctx, pageUrl)
+    }
+
+    r.mu.Lock()
+    host, ok := r.hosts[u.Host]
+    if !ok {
+        host = &robotsHost{}
+        r.hosts[u.Host] = host
+    }
+    r.mu.Unlock()
+
+    host.once.Do (func (){
+        robotsUrl := u.Scheme + "://" + u.Host + "/robots.txt"
+        body, err := r.Fetcher.Get (ctx, robotsUrl)
+        if err != nil {
+            debug ("No robots.txt at", robotsUrl, err)
+            return
+        }
+        host.rules = parseRobots (body)
+        info ("Fetched the robots.txt", robotsUrl)
+    })
+
+    path := u.EscapedPath()
+    if u.RawQuery != "" {
+        path += "?" + u.RawQuery
+    }
+    if !host.rules.allowed (path) {
+        return "", robotsError{pageUrl}
+    }
+    return r.Fetcher.Get (ctx, pageUrl)
+}
+
+// parseRobots provides the rules of robots.txt for all the robots, i.e. those of the
+// groups having User-agent: *
+func parseRobots (body string) robotsRules {
+
+    var rules robotsRules
+    applies := false
+    agents := false     // whether within the User-agent lines starting a group
+
+    for _, line := range strings.Split(body, "\n") {
+        if i := strings.Index (line, "#"); i != -1 {
+            line = line[:i]
+        }
+        colon := strings.Index (line, ":")
+        if colon == -1 {
+            continue
+        }
+        field := strings.ToLower(strings.TrimSpace(line[:colon]))
+        value := strings.TrimSpace(line[colon+1:])
+
+        switch field {
+        case "user-agent":
+            if !agents {
+                // a new group
+                applies = false
+            }
+            agents = true
+            applies = applies || value == "*"
+        case "allow", "disallow":
+            agents = false
+            // an empty Disallow allows everything, same as no rule
+            if applies && value != "" {
+                rules = append (rules, robotsRule{field == "allow", len (value), robotsPattern (value)})
+            }
+        }
+    }
+    return rules
+}
+
+// robotsPattern compiles the path of a rule, with * for any characters & a trailing $
+// for the end of the URL.
+func robotsPattern (path string) *regexp.Regexp {
+
+    end := strings.HasSuffix (path, "$")
+    path = strings.TrimSuffix (path, "$")
+
+    pattern := "^" + strings.Replace (regexp.QuoteMeta(path), `\*`, ".*", -1)
+    if end {
+        pattern += "$"
+    }
+    return regexp.MustCompile (pattern)
+}
+
+// allowed tells whether the path (with the query) of a URL is allowed by the rules.
+func (rules robotsRules) allowed (path string) bool {
+
+    allow, longest := true, -1
+    for _, rule := range rules {
+        if !rule.pattern.MatchString (path) {
+            continue
+        }
+        if rule.length > longest || (rule.length == longest && rule.allow) {
+            allow, longest = rule.allow, rule.length
+        }
+    }
+    return allow
+}
@@ -0,0 +1,71 @@
+/*
+ *-----------------------------------------------------------------
+ * IMDb Chart Fetcher - Selector Overrides
+ *-----------------------------------------------------------------
+ * Description: User supplied regular expressions to extract the
+ *              fields of the detail page, overriding the built-in
+ *              parsing, so that the program can be kept working
+ *              through a change of IMDb's markup without waiting for
+ *              a release, e.g.
+ *                -extract-duration-regex='<time[^>]*>\s*([^<]+?)\s*</time>'
+ *              The first capturing group of the match is the value of
+ *              the field. A regexp that does not match gives an empty
+ *              value, the built-in parsing only runs for the fields
+ *              without a regexp. The regexps are validated upfront.
+ *-----------------------------------------------------------------
+ */
+package imdb
+
+import (
+    "fmt"
+    "regexp"
+    "strings"
+)
+
+// fields of the detail page which can be overridden
+const (
+    field_Summary  = `summary`
+    field_Duration = `duration`
+    field_Genre    = `genre`
+)
+
+// compiled regexps of the overridden fields
+var fieldOverrides = map[string]*regexp.Regexp {}
+
+// compileFieldOverrides compiles the given regexps of the fields, skipping the empty
+// ones. Each regexp should have a capturing group for the value.
+func compileFieldOverrides (exprs map[string]string) (map[string]*regexp.Regexp, error) {
+
+    overrides := map[string]*regexp.Regexp {}
+    for field, expr := range exprs {
+        if expr == "" {
+            continue
+        }
+        re, err := regexp.Compile (expr)
+        if err != nil {
+            return nil, fmt.Errorf ("Invalid regexp for the %s: %v", field, err)
+        }
+        if re.NumSubexp() < 1 {
+            return nil, fmt.Errorf ("Invalid regexp for the %s: no capturing group for the value", field)
+        }
+        overrides[field] = re
+    }
+    return overrides, nil
+}
+
+// overrideField extracts the field from the page using its regexp. The second value
+// tells whether the field is overridden at all, in which case the built-in parsing
+// is not to be done.
+func overrideField (field string, respBody string) (string, bool) {
+
+    re, ok := fieldOverrides[field]
+    if !ok {
+        return "", false
+    }
+    m := re.FindStringSubmatch(respBody)
+    if m == nil {
+        warn ("FAILURE", "Could not obtain the", field, "using the given regexp")
+        return "", true
+    }
+    return strings.TrimSpace(m[1]), true
+}
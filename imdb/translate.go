@@ -0,0 +1,102 @@
+/*
+ *-----------------------------------------------------------------
+ * IMDb Chart Fetcher - Translation Hook
+ *-----------------------------------------------------------------
+ * Description: Translates the summary of the movies to the language
+ *              given by -translate-to, via the translation endpoint
+ *              given by -translate-url, stored as translated_summary
+ *              alongside the original one. Off by default.
+ *              The endpoint is called as LibreTranslate does it:
+ *                POST {"q": "...", "source": "auto", "target": "en", "format": "text"}
+ *                  -> {"translatedText": "..."}
+ *              The calls are made one at a time & a 429 (Too Many
+ *              Requests) is retried after the time given by its
+ *              Retry-After, a few times. On any failure the summary
+ *              is just not translated.
+ *-----------------------------------------------------------------
+ */
+package imdb
+
+import (
+    "fmt"
+    "sync"
+    "time"
+    "bytes"
+    "context"
+    "strconv"
+    "net/http"
+    "encoding/json"
+)
+
+// retries of a translation on 429 & the wait when the endpoint does not say
+const (
+    translate_Retries   = 3
+    translate_RetryWait = time.Second
+)
+
+// client for the translation endpoint, which is not IMDb
+var translateClient = &http.Client{Timeout: 30 * time.Second}
+
+// one translation at a time, so as not to flood the endpoint
+var translateMu sync.Mutex
+
+// Structure to maintain the request to the translation endpoint
+// facilitates easy conversion from structure to json by using the meta-fields
+type translateRequest struct {
+    Text   string `json:"q"`
+    Source string `json:"source"`
+    Target string `json:"target"`
+    Format string `json:"format"`
+}
+
+// translate provides the text translated to the target language by the endpoint.
+// The retries give up once the context is done.
+func translate (ctx context.Context, endpoint string, text string, target string) (string, error) {
+
+    payload, err := json.Marshal (translateRequest{text, "auto", target, "text"})
+    if err != nil {
+        return "", err
+    }
+
+    translateMu.Lock()
+    defer translateMu.Unlock()
+
+    for attempt := 0; ; attempt++ {
+        req, err := http.NewRequestWithContext (ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+        if err != nil {
+            return "", err
+        }
+        req.Header.Set("Content-Type", "application/json")
+        resp, err := translateClient.Do (req)
+        if err != nil {
+            return "", err
+        }
+
+        // slow down as asked for by the endpoint
+        if resp.StatusCode == http.StatusTooManyRequests && attempt < translate_Retries {
+            resp.Body.Close()
+            wait := translate_RetryWait
+            if secs, err := strconv.Atoi (resp.Header.Get("Retry-After")); err == nil {
+                wait = time.Duration(secs) * time.Second
+            }
+            select {
+            case <-time.After (wait):
+            case <-ctx.Done():
+                return "", ctx.Err()
+            }
+            translationRetries.Add(1)
+            continue
+        }
+
+        var result struct {
+            TranslatedText string `json:"translatedText"`
+        }
+        if resp.StatusCode != http.StatusOK {
+            err = fmt.Errorf ("Translation failed. Response Code: %d", resp.StatusCode)
+        } else {
+            err = json.NewDecoder(resp.Body).Decode(&result)
+        }
+        resp.Body.Close()
+        return result.TranslatedText, err
+    }
+}
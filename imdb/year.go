@@ -0,0 +1,73 @@
+/*
+ *-----------------------------------------------------------------
+ * IMDb Chart Fetcher - Release Year
+ *-----------------------------------------------------------------
+ * Description: Parsing of the release year as rendered by IMDb,
+ *              e.g. 1987. A year out of the sane range, i.e. before
+ *              1888 (the first motion picture) or more than 5 years
+ *              from now (the titles announced), is taken as a parsing
+ *              glitch: the year is left 0 & the movie_release_year
+ *              is named in the errors of the movie.
+ *              The TV series have a range of years instead, e.g.
+ *              2019–2023, or 2019– (or 2019– ) while still running,
+ *              the start being the release year & the end (if any)
+ *              the end year.
+ *-----------------------------------------------------------------
+ */
+package imdb
+
+import (
+    "fmt"
+    "time"
+    "regexp"
+    "strings"
+    "strconv"
+)
+
+// bounds of the sane release years, the latest being relative to the current year
+const (
+    year_Earliest = 1888
+    year_Ahead    = 5
+)
+
+// range of years of a TV series, with an en dash (or a hyphen) & no end if running
+var yearRangeRegexp = regexp.MustCompile (`^(\d+)\s*[–-]\s*(\d+)?$`)
+
+// parseYear parses the release year from the text, failing if it is not a number or
+// not a sane year.
+func parseYear (text string) (uint64, error) {
+
+    year, err := strconv.ParseUint(strings.TrimSpace(text), 10, 64)
+    if err != nil {
+        return 0, err
+    }
+    if year < year_Earliest || year > uint64(time.Now().Year() + year_Ahead) {
+        return 0, fmt.Errorf ("Release year %d out of range", year)
+    }
+    return year, nil
+}
+
+// parseYearRange parses the release year from the text, or the start & the end year
+// if it is a range of years. The end is 0 if there is none.
+func parseYearRange (text string) (uint64, uint64, error) {
+
+    text = strings.TrimSpace(text)
+    m := yearRangeRegexp.FindStringSubmatch(text)
+    if m == nil {
+        year, err := parseYear (text)
+        return year, 0, err
+    }
+
+    start, err := parseYear (m[1])
+    if err != nil {
+        return 0, 0, err
+    }
+    if m[2] == "" {
+        return start, 0, nil
+    }
+    end, err := parseYear (m[2])
+    if err != nil || end < start {
+        return 0, 0, fmt.Errorf ("Invalid range of years %q", text)
+    }
+    return start, end, nil
+}
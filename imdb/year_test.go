@@ -0,0 +1,146 @@
+/*
+ *-----------------------------------------------------------------
+ * IMDb Chart Fetcher - Tests of the release year
+ *-----------------------------------------------------------------
+ */
+package imdb
+
+import (
+    "fmt"
+    "time"
+    "strings"
+    "testing"
+    "encoding/json"
+)
+
+func TestParseYear (t *testing.T) {
+
+    latest := time.Now().Year() + year_Ahead
+    tests := []struct {
+        text string
+        year uint64
+        ok   bool
+    }{
+        {"1987", 1987, true},
+        {" 1987\n", 1987, true},
+        {"1888", 1888, true},
+        {fmt.Sprint (latest), uint64(latest), true},
+        {"1887", 0, false},
+        {fmt.Sprint (latest + 1), 0, false},
+        {"19870", 0, false},
+        {"0", 0, false},
+        {"", 0, false},
+        {"-1987", 0, false},
+        {"(1987)", 0, false},
+        {"I 1987", 0, false},
+    }
+
+    for _, tt := range tests {
+        year, err := parseYear (tt.text)
+        if year != tt.year || (err == nil) != tt.ok {
+            t.Errorf ("parseYear(%q) = %d, %v, want %d", tt.text, year, err, tt.year)
+        }
+    }
+}
+
+func TestParseYearRange (t *testing.T) {
+
+    tests := []struct {
+        text  string
+        start uint64
+        end   uint64
+        ok    bool
+    }{
+        {"2019", 2019, 0, true},
+        {"2019–2023", 2019, 2023, true},
+        {"2019 – 2023", 2019, 2023, true},
+        {"2019-2023", 2019, 2023, true},
+        {"2019–", 2019, 0, true},
+        {"2019– ", 2019, 0, true},
+        {"2019–2019", 2019, 2019, true},
+        {"2023–2019", 0, 0, false},
+        {"2019–20230", 0, 0, false},
+        {"1700–2019", 0, 0, false},
+        {"(2019–)", 0, 0, false},
+        {"–2019", 0, 0, false},
+        {"2019––2023", 0, 0, false},
+        {"", 0, 0, false},
+    }
+
+    for _, tt := range tests {
+        start, end, err := parseYearRange (tt.text)
+        if start != tt.start || end != tt.end || (err == nil) != tt.ok {
+            t.Errorf ("parseYearRange(%q) = %d, %d, %v, want %d, %d", tt.text, start, end, err, tt.start, tt.end)
+        }
+    }
+}
+
+// the year of the title column as in the markup of the chart, within parentheses
+func TestTitleRowYear (t *testing.T) {
+
+    tests := []struct {
+        span  string
+        start uint64
+        end   uint64
+        ok    bool
+    }{
+        {"(1987)", 1987, 0, true},
+        {"(2019–)", 2019, 0, true},
+        {"( 2019– )", 2019, 0, true},
+        {"(19870)", 0, 0, false},
+        {"(1066)", 0, 0, false},
+        {"(2019–20230)", 0, 0, false},
+        {"(TV Series)", 0, 0, false},
+    }
+
+    for _, tt := range tests {
+        var (
+            got  TitleData
+            errs []FieldError
+        )
+        row := `<td class="titleColumn"><a href="/title/tt0000001/">Nayakan</a> <span class="secondaryInfo">` + tt.span + `</span></td>`
+        parseTitleRow (row, &got, &errs)
+        if got.ReleaseYear != tt.start || got.EndYear != tt.end {
+            t.Errorf ("%s: years %d & %d, want %d & %d", tt.span, got.ReleaseYear, got.EndYear, tt.start, tt.end)
+        }
+        if (len (errs) == 0) != tt.ok || (!tt.ok && errs[0].Field != field_ReleaseYear) {
+            t.Errorf ("%s: errors %v", tt.span, errs)
+        }
+    }
+}
+
+// the single years of the miniseries, & the ranges of the series running or ended
+func TestSeriesYears (t *testing.T) {
+
+    o := DefaultOptions()
+    o.Details = false
+    configureTest (t, o, nil)
+
+    want := []struct {
+        title string
+        start uint64
+        end   uint64
+    }{
+        {"Sacred Games", 2018, 2019},
+        {"Panchayat", 2020, 0},
+        {"Scam 1992", 2020, 0},
+        {"Suzhal", 2022, 0},
+    }
+    chart := parseChart (t, fixture (t, "series.html"), 0)
+    if len (chart.Movies) != len (want) {
+        t.Fatalf ("%d series, want %d", len (chart.Movies), len (want))
+    }
+    for i, mov := range chart.Movies {
+        if mov.Title != want[i].title || mov.ReleaseYear != want[i].start || mov.EndYear != want[i].end {
+            t.Errorf ("series %d is %s of %d to %d, want %+v", i, mov.Title, mov.ReleaseYear, mov.EndYear, want[i])
+        }
+        if len (mov.Errors) != 0 {
+            t.Errorf ("series %d has errors %v", i, mov.Errors)
+        }
+        // the end year is left out of the output when there is none
+        encoded, _ := json.Marshal (mov)
+        if strings.Contains (string(encoded), `"end_year"`) != (want[i].end != 0) {
+            t.Errorf ("series %d encoded as %s", i, encoded)
+        }
+    }
+}
@@ -7,12 +7,23 @@
  *              JSON string of the obtained list of movies from the
  *              IMDb website.
  *              The following details of the movies are fetched:
+ *               - rank on the chart
+ *               - imdb title ID
  *               - title
  *               - movie release year
+ *               - URL of the detail page
  *               - imdb rating
+ *               - number of votes
  *               - summary
- *               - duration
+ *               - storyline (optional)
+ *               - translated summary (optional)
+ *               - duration, also in minutes
  *               - genre
+ *               - title type
+ *               - directors & stars
+ *               - principal cast
+ *               - weekend & total gross, weeks in release (box-office)
+ *               - fields failed to be parsed, if any
  *              The program utilizes the concept of Web scraping &
  *              Web Crawling to get the movie details from the URL.
  *
@@ -25,12 +36,250 @@
  * DISTRIB_DESCRIPTION="Ubuntu 20.04.1 LTS"
  *
  * Usage:
- * ./imdb_chart_fetcher 'chart_url' items_count
+ * ./imdb_chart_fetcher [options] 'chart_url' items_count
  * where
- *  - items_count is the number of movies needed
- *  - chart_url is the IMDb URL to fetch the data from
+ *  - items_count is the number of movies needed, 0 for all of them
+ *  - chart_url is the IMDb URL to fetch the data from, either one
+ *    of the charts (e.g. https://www.imdb.com/chart/top, any chart
+ *    having the table layout) or a keyword search (https://www.imdb.
+ *    com/search/keyword?keywords=...). See imdb/keyword.go. The pages
+ *    of a paginated list are followed as needed, see imdb/pages.go
  *  - imdb_chart_fetcher is the binary
  *
+ * Options:
+ *  -lite   output only the IMDb title ID, title, release year,
+ *          rating & URL of the movies. The detail pages are not
+ *          crawled.
+ *  -replay=archive.zip
+ *          serve the pages from an archive (zip or tar) of saved
+ *          responses instead of the network. See imdb/replay.go
+ *  -type=Movie,TVSeries
+ *          keep only the titles of the given types (JSON-LD @type
+ *          of the detail page, e.g. Movie, TVSeries, TVEpisode)
+ *  -warnings-out=warnings.jsonl
+ *          write the warnings as JSON records, one per line, to the
+ *          given file (or fd e.g. /dev/fd/3) instead of stderr
+ *  -from=M -to=N
+ *          fetch only the window of chart ranks M through N. The
+ *          items_count still applies within the window.
+ *  -genre-report
+ *          output the number of movies & their mean/median rating
+ *          for each genre instead of the movies. See report.go
+ *  -envelope
+ *          wrap the output in an object with the version of its shape,
+ *          the chart URL & title, the time of generation & the number
+ *          of movies requested, returned & available:
+ *          {"schema_version":2,"source_url":"..","chart":"..",
+ *          "generated_at":"..","requested_count":..,"returned_count":..,
+ *          "available_count":..,"movies":[..]}
+ *  -min-votes=N [-unknown-votes=keep|drop]
+ *          drop the movies having fewer than N votes. The movies whose
+ *          number of votes is unknown are kept by default.
+ *  -pretty-duration
+ *          present the duration uniformly as e.g. "2h 6m" regardless
+ *          of how IMDb renders it. See imdb/duration.go
+ *  -debug-addr=localhost:6060
+ *          serve the runtime counters (expvar) at /debug/vars on the
+ *          given address while the program runs. See imdb/metrics.go
+ *          With the prometheus build tag, /metrics is served too.
+ *  -max-redirects=N
+ *          follow at most N redirects, 0 to not follow any. A redirect
+ *          that is not followed is reported along with its Location.
+ *  -new-since=baseline.json
+ *          output only the movies absent (by IMDb title ID) from the
+ *          baseline, an earlier output. See imdb/baseline.go
+ *  -adaptive=N [-adaptive-latency=2s]
+ *          limit the concurrent requests adaptively, up to N. It slows
+ *          down on 429/503 or slow responses & speeds back up as they
+ *          recover. See imdb/adaptive.go
+ *  -tv-episodes
+ *          crawl the episodes (title, season, number, air date &
+ *          rating) of the TV series too. One request per season, so
+ *          this is expensive. See imdb/episodes.go
+ *  -collapse-genres [-genre-map=genres.json]
+ *          map the genres to a handful of buckets as well, using the
+ *          built-in mapping or the given JSON of {genre: bucket}.
+ *          See imdb/genres.go
+ *  -pool-idle-timeout=90s
+ *          how long an idle keep-alive connection to IMDb is kept for
+ *          reuse, 0 to keep it indefinitely
+ *  -storyline
+ *          fetch the storyline i.e. the long summary, from the plot
+ *          summary page as well. One more request per movie, up to
+ *          -max-summary-requests=N in all if given.
+ *  -group-by=decade
+ *          output the movies grouped by the decade of their release
+ *          e.g. {"1980s": [...], "unknown": [...]}. See report.go
+ *  -user-agent="Mozilla/5.0 ..."
+ *          User-Agent of every request, a browser's by default as IMDb
+ *          serves a different page or a 403 to the others.
+ *  -user-agents=agents.txt
+ *          pick the User-Agent of each request at random from the file,
+ *          one per line. Best-effort against being blocked on big
+ *          crawls, not a guarantee. -user-agent by default.
+ *  -format=json|sql|links|csv|ndjson [-sql-table=movies]
+ *          output as JSON, the default, or as an SQL dump i.e. CREATE
+ *          TABLE & INSERT statements for the movies. See sqldump.go
+ *          links gives "Title (Year) — URL" per line, from the chart
+ *          alone i.e. without crawling the detail pages.
+ *          csv gives the title, year, rating, duration, genre & summary
+ *          of each movie as CSV, after a header row. See csvout.go
+ *          ndjson gives a line of JSON per movie as soon as it is
+ *          crawled, in the order of completion. See stream.go
+ *          With the parquet build tag, -format=parquet writes a Parquet
+ *          file instead, e.g. -format=parquet > movies.parquet
+ *  -ids-from=ids.txt
+ *          fetch the details of the IMDb title IDs (e.g. tt0093603) in
+ *          the file, one per line, instead of a chart. The URL & the
+ *          count are not needed. See imdb/ids.go
+ *  -sort=rank|rating|votes|year|title [-sort-stable=true]
+ *          sort the movies by the field, descending if prefixed with
+ *          - or suffixed with -desc, e.g. -sort=-votes, rating-desc.
+ *          The movies having equal values stay in the chart order
+ *          unless -sort-stable=false. The sorting is of the movies
+ *          fetched, i.e. items_count is of the chart order.
+ *  -delay=200ms
+ *          minimum interval between the starts of the requests to IMDb
+ *          (chart, detail pages, summaries etc.) across all of them.
+ *          Simple & predictable, -adaptive is the more precise option.
+ *  -cookie="name=value; ..."
+ *          Cookie header to send with every request, e.g. the cookies
+ *          of the browser once the consent/age-gate page of IMDb is
+ *          accepted there. See imdb/interstitial.go
+ *  -record-config=run.json
+ *          write the effective options of the run along with the URL,
+ *          count, version & start time to the file, as the provenance
+ *          of the output. Also part of the envelope. See config.go
+ *  -min-rating=8
+ *          drop the movies rated lower than the given rating. The count
+ *          is then of the first movies rated at least that & passing
+ *          the other filters, scanning the chart further as needed.
+ *          Implies -lazy-details.
+ *  -lazy-details
+ *          crawl the detail pages only for the movies that pass the
+ *          filters on the chart data (-min-rating, -min-votes, -new-
+ *          since) rather than for every movie. Fewer requests for a
+ *          filtered crawl, but the crawl starts after the chart.
+ *  -extract-summary-regex=RE, -extract-duration-regex=RE,
+ *  -extract-genre-regex=RE
+ *          take the first group of the regexp matched in the detail
+ *          page as the field, instead of the built-in parsing. To keep
+ *          working through changes of the markup. See imdb/selectors.go
+ *  -tee=file.json
+ *          write the output to the file as well as to the standard
+ *          output, e.g. to keep a copy while piping it downstream.
+ *  -translate-to=en -translate-url=http://localhost:5000/translate
+ *          translate the summary to the language via the endpoint,
+ *          as translated_summary. See imdb/translate.go
+ *  -timeout=15s
+ *          time limit of each request to IMDb. A movie whose detail
+ *          page times out is output without the details.
+ *  -normalize-title=auto|on|off
+ *          strip the leading rank e.g. "1. " from the titles. By default
+ *          only for the charts known to prefix the title with the rank.
+ *  -allow-any-url
+ *          accept the chart URL of any host rather than only imdb.com,
+ *          e.g. a mirror or a local copy. It should still be a valid
+ *          http(s) URL.
+ *  -pretty
+ *          indent the JSON output by two spaces for reading, instead of
+ *          the compact single line.
+ *  -concurrency=8
+ *          maximum number of requests to IMDb in flight at a time, shared
+ *          by the movies, their details, storylines & episodes. The
+ *          movies are still in the chart order. 0 for no limit.
+ *  -output=file.json
+ *          write the output to the file (created or truncated) instead
+ *          of the standard output. The errors still go to the standard
+ *          output, the file being left incomplete.
+ *  -log-level=error
+ *          verbosity of the log on the standard error: error (default)
+ *          for the errors only, warn for the warnings as well, info for
+ *          the progress per movie & debug for the parsing details. See
+ *          imdb/logging.go
+ *  -proxy=http://proxy:3128
+ *          route the requests to IMDb through the proxy, http(s) or
+ *          socks5. The proxy of the environment (HTTPS_PROXY etc.) if
+ *          not given.
+ *  -fields=title,imdb_rating
+ *          output only the given fields of the movies, named as in the
+ *          JSON. The detail pages are crawled only if any of them is
+ *          from there e.g. summary. See fields.go
+ *  -ignore-robots
+ *          request the pages disallowed by the robots.txt of IMDb as
+ *          well. By default such a chart fails the run & such detail
+ *          pages are skipped, leaving the details empty. See
+ *          imdb/robots.go
+ *  -cache-dir=.cache
+ *          cache the detail pages in the directory, so that the runs
+ *          that follow take them from there. See imdb/cache.go
+ *  -cache-ttl=24h
+ *          age beyond which a cached detail page is fetched afresh, 0
+ *          for no limit
+ *  -input=chart.html
+ *          read the chart page from the file (- for the standard input)
+ *          instead of fetching it, chart_url still telling its layout.
+ *          Entirely offline along with -lite, the detail pages being
+ *          fetched otherwise.
+ *  -max-summary=200
+ *          truncate the summary & the storyline to the number of
+ *          characters, at the end of a word, marking the cut by …
+ *  -ca-cert=corporate-ca.pem
+ *          trust the CA certificates of the PEM file along with those
+ *          of the system, e.g. of a TLS-inspecting proxy
+ *  -insecure
+ *          DANGEROUS: skip the verification of the TLS certificates, so
+ *          that anyone on the way can read & alter the traffic. Only for
+ *          test setups, -ca-cert is the way for a proxy.
+ *  -strict
+ *          fail the run (code 5) without any output if any field of the
+ *          movies could not be fetched or parsed, for the pipelines that
+ *          need complete data. Not with -format=ndjson.
+ *  -count-only
+ *          output only the number of the movies the chart lists (all of
+ *          its pages), e.g. {"available":250}, without crawling any of
+ *          them. items_count may be left out.
+ *  -include-poster-url
+ *          output the URL of the poster image of each movie as poster_url,
+ *          the higher resolution one of the detail page, else (e.g. with
+ *          -lite) the thumbnail of the chart.
+ *  -chart=tamil
+ *          fetch the chart of the given name (indian, tamil, telugu or
+ *          boxoffice) instead of the chart_url, which is left out, i.e.
+ *          ./imdb_chart_fetcher -chart=tamil items_count
+ *  -stats  log a line of the number of requests made to IMDb & of the
+ *          failed requests (& of the translations retried with
+ *          -translate-to) & the time taken by the run to the standard
+ *          error at its end, e.g.
+ *          STATS: 252 requests, 2 failed in 41.2s
+ *  -detail-concurrency=N
+ *          allow at most N requests of the detail pages & storylines in
+ *          flight at a time, within -concurrency, keeping them gentle
+ *          while the chart & its pages are not held up.
+ *  -ascii  escape the characters beyond ASCII in the JSON output as
+ *          \uXXXX, for the consumers that cannot take UTF-8. See ascii.go
+ *  -dry-run
+ *          fetch the chart only & output the URLs of the detail pages a
+ *          full run would crawl (after the filters) instead of the
+ *          movies, e.g. {"chart":"..","details":["..",..]}
+ *  -error-log=failed.jsonl
+ *          write each of the movies that could not be fetched in full
+ *          to the file as a line of JSON with its rank, ID, title,
+ *          detail URL & errors, for a re-run. See errorlog.go
+ *
+ * A failed run outputs {"error": "...", "code": N} instead of the
+ * movies & exits with the code N: 1 for a failure, 2 for invalid
+ * arguments or options, 3 when the chart cannot be fetched & 4 when
+ * it has no list of movies, e.g. its layout has changed.
+ * When some of the fields of the movies could not be fetched or
+ * parsed, the movies are output as they are but the run exits with
+ * the code 5, unless -strict fails it instead.
+ * Ctrl-C (or SIGTERM) abandons the crawl, cancelling the requests in
+ * flight, & fails the run.
+ *
+ * The scraping is done by the imdb package (./imdb), this program
+ * being a thin command-line wrapper of it. See imdb/imdb.go
+ *
  * The binary, imdb_chart_fetcher should be present but it is highly
  * recommended that the binary be created for the system on which it
  * is to be executed.
@@ -38,7 +287,9 @@
  * To create the binary:
  *  - Navigate to the folder containing source code [main.go] file
  *    Make sure the GOPATH is set to point to the workspace where
- *    this program is kept.
+ *    this program is kept, i.e. the folder is
+ *    $GOPATH/src/github.com/sadhroh/Imdb-crawler so that the imdb
+ *    package is found.
  *  - Enter the line:
  *    go build -o imdb_chart_fetcher .
  *  - This should create the executable binary in the current folder
@@ -47,323 +298,737 @@
  */
 package main
 
-// NO external frameworks/packages are used. Packages already present in golang v1.15.3 are used
+// NO external frameworks/packages are used. Packages already present in golang v1.15.3 are used,
+// besides the scraping of IMDb itself which is the imdb package of this repository
 import (
+    "io"
     "os"
     "fmt"
     "log"
-    "sync"
-    "regexp"
+    "flag"
+    "time"
+    "context"
     "strings"
     "strconv"
+    "syscall"
     "net/http"
     "io/ioutil"
+    "os/signal"
     "encoding/json"
-)
 
-// IMDB URL constants for web crawling/scraping
-const (
-    imdb_url_Main    = `https://www.imdb.com`
-    chart_url_Indian = `https://www.imdb.com/india/top-rated-indian-movies`
-    chart_url_Tamil  = `https://www.imdb.com/india/top-rated-tamil-movies`
-    chart_url_Telugu = `https://www.imdb.com/india/top-rated-telugu-movies`
+    "github.com/sadhroh/Imdb-crawler/imdb"
 )
 
-// HTML element classes used as selectors to find the element
-const (
-    td_titleClass     = `titleColumn`
-    td_ratingClass    = `ratingColumn imdbRating`
-    releaseYear_class = `secondaryInfo`
-    summary_class     = `summary_text`
+// command-line options
+var (
+    liteOutput       = flag.Bool ("lite", false, "output only the IMDb title ID, title, release year, rating & URL of the movies")
+    replayArchive    = flag.String ("replay", "", "serve all the fetches from the given zip/tar archive of saved responses")
+    titleTypes       = flag.String ("type", "", "comma separated title types to keep, e.g. Movie,TVSeries")
+    warningsOut      = flag.String ("warnings-out", "", "write the warnings as JSON records to the given file instead of stderr")
+    rankFrom         = flag.Int ("from", 1, "chart rank of the first movie to fetch")
+    rankTo           = flag.Int ("to", 0, "chart rank of the last movie to fetch, 0 for no limit")
+    genreReportOn    = flag.Bool ("genre-report", false, "output the count, mean & median rating of each genre instead of the movies")
+    envelopeOut      = flag.Bool ("envelope", false, "wrap the output in an object along with the chart metadata")
+    minVotes         = flag.Uint64 ("min-votes", 0, "drop the movies having fewer votes than this")
+    unknownVotes     = flag.String ("unknown-votes", "keep", "keep or drop the movies whose number of votes is unknown, with -min-votes")
+    prettyDurationOn = flag.Bool ("pretty-duration", false, "present the duration uniformly as e.g. 2h 6m")
+    debugAddr        = flag.String ("debug-addr", "", "serve the runtime counters at /debug/vars on this address, e.g. localhost:6060")
+    maxRedirects     = flag.Int ("max-redirects", -1, "maximum number of redirects to follow, 0 to not follow any. Negative for the default of 10")
+    newSince         = flag.String ("new-since", "", "output only the movies absent from the given earlier output (baseline)")
+    adaptiveMax      = flag.Int ("adaptive", 0, "adapt the number of concurrent requests to IMDb's responses, up to this many. 0 to not limit")
+    adaptiveLatency  = flag.Duration ("adaptive-latency", 2 * time.Second, "response latency beyond which -adaptive slows down")
+    tvEpisodes       = flag.Bool ("tv-episodes", false, "crawl the episodes of the TV series as well, one request per season")
+    collapseGenresOn = flag.Bool ("collapse-genres", false, "map the genres to a handful of buckets as well, e.g. Thriller to Action")
+    genreMap         = flag.String ("genre-map", "", "JSON file of {genre: bucket} to use instead of the built-in buckets, implies -collapse-genres")
+    poolIdleTimeout  = flag.Duration ("pool-idle-timeout", 90 * time.Second, "how long an idle keep-alive connection to IMDb is kept for reuse")
+    storylineOn      = flag.Bool ("storyline", false, "fetch the long storyline from the plot summary page as well, one more request per movie")
+    groupBy          = flag.String ("group-by", "", "output the movies grouped by the given key instead of a list, only decade for now")
+    userAgentOpt     = flag.String ("user-agent", imdb.DefaultOptions().UserAgent, "User-Agent of the requests to IMDb")
+    userAgentsFile   = flag.String ("user-agents", "", "file of User-Agents, one per line, to pick from at random for each request")
+    outputFormat     = flag.String ("format", "json", "output format: json, sql for CREATE TABLE & INSERT statements, links for a line of title, year & URL per movie, csv, or ndjson for a line of JSON per movie as soon as it is crawled")
+    sqlTable         = flag.String ("sql-table", "movies", "name of the table for -format=sql")
+    idsFrom          = flag.String ("ids-from", "", "fetch the details of the IMDb title IDs in the file, one per line, instead of a chart")
+    sortBy           = flag.String ("sort", "", "sort the movies by rank, rating, votes, year or title, prefixed with - or suffixed with -desc for descending. Chart order (rank) by default")
+    sortStable       = flag.Bool ("sort-stable", true, "keep the movies having equal sort keys in the chart order")
+    requestDelay     = flag.Duration ("delay", 0, "minimum interval between the starts of the requests to IMDb, e.g. 200ms. 0 for none")
+    cookieHeader     = flag.String ("cookie", "", "Cookie header to send with the requests, e.g. copied from the browser after giving consent")
+    recordConfig     = flag.String ("record-config", "", "write the effective options of the run to the given JSON file, & into the envelope")
+    minRating        = flag.Float64 ("min-rating", 0, "drop the movies rated lower than this, e.g. 8. The count is then of the first movies passing it & the other filters")
+    lazyDetails      = flag.Bool ("lazy-details", false, "crawl the detail pages only for the movies passing -min-rating, -min-votes & -new-since")
+    summaryRegex     = flag.String ("extract-summary-regex", "", "regexp whose first group is taken as the summary, instead of the built-in parsing")
+    durationRegex    = flag.String ("extract-duration-regex", "", "regexp whose first group is taken as the duration, instead of the built-in parsing")
+    genreRegex       = flag.String ("extract-genre-regex", "", "regexp whose first group is taken as the comma separated genres, instead of the built-in parsing")
+    maxSummaryReqs   = flag.Int ("max-summary-requests", 0, "maximum number of storyline requests of a crawl, with -storyline. 0 for no limit")
+    teeOut           = flag.String ("tee", "", "write the output to the given file as well as to the standard output")
+    translateTo      = flag.String ("translate-to", "", "translate the summary to this language, e.g. en, via -translate-url")
+    translateURL     = flag.String ("translate-url", "", "LibreTranslate compatible endpoint to translate the summary with, e.g. http://localhost:5000/translate")
+    requestTimeout   = flag.Duration ("timeout", 15 * time.Second, "time limit of each request to IMDb, 0 for no limit")
+    normalizeTitle   = flag.String ("normalize-title", "auto", "strip the leading rank e.g. \"1. \" from the titles: on, off or auto for the charts known to have it")
+    allowAnyURL      = flag.Bool ("allow-any-url", false, "accept a chart URL of any host, not only imdb.com, e.g. a mirror")
+    prettyJSON       = flag.Bool ("pretty", false, "indent the JSON output by two spaces, for reading")
+    concurrency      = flag.Int ("concurrency", 8, "maximum number of requests to IMDb in flight at a time, 0 for no limit")
+    outputFile       = flag.String ("output", "", "write the output to the given file instead of the standard output")
+    logLevel         = flag.String ("log-level", "error", "verbosity of the log on the standard error: error, warn, info or debug")
+    proxyOpt         = flag.String ("proxy", "", "http(s) or socks5 URL of the proxy to route the requests through, e.g. socks5://localhost:1080")
+    fieldsOpt        = flag.String ("fields", "", "comma separated fields of the movies to output, e.g. title,imdb_rating. The detail pages are crawled only if any of them needs")
+    ignoreRobots     = flag.Bool ("ignore-robots", false, "request the pages disallowed by the robots.txt of IMDb as well")
+    cacheDir         = flag.String ("cache-dir", "", "directory to cache the detail pages in across the runs, none if empty")
+    cacheTTL         = flag.Duration ("cache-ttl", 24 * time.Hour, "age beyond which a cached detail page is fetched afresh, 0 for no limit")
+    inputFile        = flag.String ("input", "", "read the chart page from the given file, - for the standard input, instead of fetching it. The detail pages are still fetched unless -lite")
+    maxSummary       = flag.Int ("max-summary", 0, "number of characters to truncate the summary & the storyline to, at a word boundary with …. 0 for no limit")
+    caCert           = flag.String ("ca-cert", "", "PEM file of CA certificates to trust along with those of the system, e.g. of a TLS-inspecting proxy")
+    insecureTLS      = flag.Bool ("insecure", false, "DANGEROUS: skip the verification of the TLS certificates, only for test setups")
+    strictRun        = flag.Bool ("strict", false, "fail the run without any output if any of the fields of the movies could not be fetched or parsed")
+    countOnly        = flag.Bool ("count-only", false, "output only the number of the movies the chart lists, e.g. {\"available\":250}, crawling none of them. The items_count may be left out")
+    posterURL        = flag.Bool ("include-poster-url", false, "output the URL of the poster image of the movies as poster_url, that of the detail page unless -lite")
+    chartName        = flag.String ("chart", "", "name of the chart to fetch instead of giving its URL: indian, tamil, telugu or boxoffice. Only the count is given then")
+    statsOn          = flag.Bool ("stats", false, "log a line of the number of requests & failed requests (& translation retries) & the time taken to the standard error at the end")
+    detailConc       = flag.Int ("detail-concurrency", 0, "maximum number of detail page & storyline requests in flight at a time, within -concurrency. 0 for no limit of their own")
+    dryRun           = flag.Bool ("dry-run", false, "fetch the chart only & output the URLs of the detail pages a full run would crawl, as JSON, instead of the movies")
+    errorLog         = flag.String ("error-log", "", "write each of the movies that could not be fetched in full to the given file as a line of JSON with the errors, for a re-run")
+    asciiOut         = flag.Bool ("ascii", false, "escape the characters beyond ASCII in the JSON output as \\uXXXX, e.g. of the Tamil & Telugu titles, for the consumers that cannot take UTF-8")
 )
 
-// field separator as present in IMDB for separating multiple data
+// Structure to maintain only the details available from the chart table itself,
+// i.e. rank, IMDb title ID, title, release year, rating & the URL of the movie, along with the
+// thumbnail of the poster if asked for.
+// Used for the lite output where the detail pages are not crawled at all, hence
+// a separate structure keeps the JSON free of the empty summary, duration & genre.
+type LiteChartData struct {
+    Rank        int     `json:"rank"`
+    IMDbID      string  `json:"imdb_id"`
+    Title       string  `json:"title"`
+    ReleaseYear uint64  `json:"movie_release_year"`
+    Rating      float64 `json:"imdb_rating"`
+    URL         string  `json:"url"`
+    PosterURL   string  `json:"poster_url,omitempty"`
+}
+
+// version of the shape of the output, for the consumers to tell which one they are
+// parsing, to be bumped along with any change of the fields that is not backward
+// compatible (e.g. a field renamed or changing its type)
+const schema_Version = 2
+
+// Structure to wrap the output along with the chart level metadata, so that the
+// data is self-labelled when the outputs of many charts are archived together.
+// The counts tell whether the result was clamped to the records available or cut
+// down by the filters, without having to look at the logs. The time of generation
+// is that of the completion of the crawl.
+type ChartEnvelope struct {
+    SchemaVersion  int         `json:"schema_version"`
+    SourceURL      string      `json:"source_url,omitempty"`
+    Chart          string      `json:"chart"`
+    GeneratedAt    string      `json:"generated_at"`
+    RequestedCount int         `json:"requested_count"`
+    ReturnedCount  int         `json:"returned_count"`
+    AvailableCount int         `json:"available_count"`
+    Config         *RunConfig  `json:"config,omitempty"`
+    Movies         interface{} `json:"movies"`
+}
+
+// serializers of the output formats which need an external package & hence are
+// only built with their tag, registered by their files e.g. parquet.go
+var taggedFormats = map[string]func (imdbChartTable []imdb.ImdbChartData) (string, error) {}
+
+// titleLinks provides the movies as "Title (Year) — URL", one per line, the year
+// being left out when unknown.
+func titleLinks (imdbChartTable []imdb.ImdbChartData) string {
+
+    lines := make([]string, len (imdbChartTable))
+
+    for i, mov := range imdbChartTable {
+        if mov.ReleaseYear != 0 {
+            lines[i] = fmt.Sprintf ("%s (%d) — %s", mov.Title, mov.ReleaseYear, mov.DetailURL)
+        } else {
+            lines[i] = fmt.Sprintf ("%s — %s", mov.Title, mov.DetailURL)
+        }
+    }
+    return strings.Join(lines, "\n")
+}
+
+// liteChart projects the fully populated chart onto the lite structure, keeping
+// only the fields available from the chart table.
+func liteChart (imdbChartTable []imdb.ImdbChartData) []LiteChartData {
+
+    liteTable := make([]LiteChartData, len (imdbChartTable))
+
+    for i, mov := range imdbChartTable {
+        liteTable[i] = LiteChartData{
+            Rank:        mov.Rank,
+            IMDbID:      mov.IMDbID,
+            Title:       mov.Title,
+            ReleaseYear: mov.ReleaseYear,
+            Rating:      mov.Rating,
+            URL:         mov.DetailURL,
+            PosterURL:   mov.PosterURL,
+        }
+    }
+    return liteTable
+}
+
+// movieRecords provides the movies as they are to be output, i.e. the lite ones with
+// -lite or only the fields given by -fields, if asked for.
+func movieRecords (imdbChartTable []imdb.ImdbChartData) interface{} {
+
+    switch {
+    case *liteOutput:
+        return liteChart (imdbChartTable)
+    case len (outputFields) > 0:
+        return pickFields (imdbChartTable, outputFields)
+    }
+    return imdbChartTable
+}
+
+// exit codes of the program, the error being output as JSON as well
 const (
-    field_separator = `<span class="ghost">|</span>`
+    exit_Failure = 1    // the run failed e.g. a file could not be read or written
+    exit_Usage   = 2    // invalid arguments or options
+    exit_Fetch   = 3    // the chart could not be fetched
+    exit_Layout  = 4    // the chart has no list of movies to parse
+    exit_Partial = 5    // some of the fields of the movies could not be fetched or parsed
 )
 
-// Structure to maintain the summary, duration & genre
+// Structure to maintain the error of a failed run, output instead of the movies so
+// that the scripts can tell a failure from the standard output as well.
 // facilitates easy conversion from structure to json by using the meta-fields
-type MovDetail struct {
-    Summary  string `json:"summary"`
-    Duration string `json:"duration"`
-    Genre    string `json:"genre"`
+type RunError struct {
+    Error string `json:"error"`
+    Code  int    `json:"code"`
 }
 
-// Structure to maintain the title, release year as well as movie details like
-// summary, duration & genre via embedding the MovDetail structure.
-// facilitates easy conversion from structure to json by using the meta-fields
-// as the emebedded structure meta fields are also taken as is.
-type TitleData struct {
-    Title       string `json:"title"`
-    ReleaseYear uint64 `json:"movie_release_year"`
-    MovDetail
+// Structure to maintain the number of the movies listed by the chart, output instead
+// of the movies for -count-only.
+type ChartCount struct {
+    Available int `json:"available"`
 }
 
-// The overall chart data which specifies the TitleData, via embedding as well
-// as the rating that is obtained separately.
-// facilitates easy conversion from structure to json by using the meta-fields
-// as the emebedded structure meta fields are also taken as is.
-type ImdbChartData struct {
-    TitleData
-    Rating      float64 `json:"imdb_rating"`
+// Structure to maintain the requests planned by -dry-run, output instead of the
+// movies, i.e. the chart fetched & the detail pages a full run would crawl.
+type DryRunPlan struct {
+    Chart   string   `json:"chart"`
+    Details []string `json:"details"`
 }
 
-// crawlForMoreInfo is a web crawler to fetch the duration, genre & summary via using
-// the link provided in the main movie table.
-// This function is triggered as a goroutine to process concurrently while other data
-// is being fetched/populated.
-func crawlForMoreInfo (cUrl string, crawlChan chan<- MovDetail){
+// start of the crawl, for -stats
+var crawlStart time.Time
 
-    var wg sync.WaitGroup
+// logStats logs the number of the requests made & the time taken since the start of
+// the crawl, if asked for by -stats & the crawl has started.
+func logStats () {
 
-    resp, err := http.Get (cUrl)
-    if err != nil{
-        log.Println ("FAILURE: Failed to establish GET request for more info")
+    if !*statsOn || crawlStart.IsZero() {
+        return
     }
-    if resp.StatusCode != http.StatusOK {
-        log.Println ("FAILURE: Cannot process response. Response Code:", resp.StatusCode)
+    stats := imdb.ReadStats()
+    cached := ""
+    if lookups := stats.CacheHits + stats.CacheMisses; lookups > 0 {
+        cached = fmt.Sprintf (", %d of %d pages cached (%.0f%%)", stats.CacheHits, lookups, 100 * stats.CacheHitRatio())
     }
-    defer resp.Body.Close()
-    body, err := ioutil.ReadAll(resp.Body)
-    if err != nil{
-        log.Println ("ERROR: Failed to obtain response body for more info")
+    // the requests to IMDb are not retried, only the translations
+    retried := ""
+    if *translateTo != "" {
+        retried = fmt.Sprintf (", %d translation retries", stats.TranslationRetries)
     }
-    if err != nil {
-        crawlChan<- MovDetail{}
-    }
-    respBody := string(body)
+    log.Printf ("STATS: %d requests, %d failed%s%s in %v", stats.Requests, stats.FetchErrors, retried, cached,
+                time.Since(crawlStart).Round(time.Millisecond))
+}
 
-    // duration
-    durEndIdx := strings.Index(respBody, `</time>`)
-    durStrtIdx := strings.LastIndex(respBody[ : durEndIdx], `>`) + 1
+// fail ends the run with the given exit code, providing the error as JSON on the
+// standard output while logging it as well.
+func fail (code int, v ...interface{}) {
 
-    // summary
-    summaryDivAttr := `<div class="`+summary_class+`">`
-    summaryStrtIdx := strings.Index(respBody, summaryDivAttr) + len (summaryDivAttr)
-    summaryEndIdx := strings.Index(respBody[summaryStrtIdx : ], `</div>`) + summaryStrtIdx
-    summaryData := []byte(strings.TrimSpace(respBody[summaryStrtIdx : summaryEndIdx]))
+    msg := fmt.Sprint (v...)
+    log.Println ("ERROR:", msg)
+    logStats()
 
-    // check if the summary is not complete and a link to the full summary is given
-    if newLnk := strings.Index (string(summaryData), `<a href="`); newLnk != -1 {
-	    newLnkEndIdx := newLnk + strings.Index(string(summaryData[newLnk + len (`<a href="`) : ]), `>`)
-	    fullSummaryUrl := imdb_url_Main + string(summaryData[newLnk + len (`<a href="`) : newLnkEndIdx])
+    out, _ := json.Marshal (RunError{msg, code})
+    if *asciiOut {
+        out = asciiJSON (out)
+    }
+    fmt.Println (string(out))
+    os.Exit (code)
+}
 
-	    wg.Add(1)
+// failCrawl ends the run for the error of crawling the chart, with the exit code as per
+// the error.
+func failCrawl (ctx context.Context, err error) {
+    code, msg := crawlFailure (ctx, err)
+    fail (code, msg, err)
+}
 
-	    // let the goroutine extract the full summary using the URL for the same
-	    go func (){
-                defer wg.Done()
+// crawlFailure provides the exit code & the message of the crawl failing with the
+// given error.
+func crawlFailure (ctx context.Context, err error) (int, string) {
 
-		resp, err := http.Get (fullSummaryUrl)
-		if err != nil{
-			log.Println ("FAILURE: Failed to establish GET request for more info")
-		}
-		if resp.StatusCode != http.StatusOK {
-			log.Println ("FAILURE: Cannot process response. Response Code:", resp.StatusCode)
-		}
-		defer resp.Body.Close()
-		body, err := ioutil.ReadAll(resp.Body)
-		if err != nil{
-			log.Println ("ERROR: Failed to obtain response body for more info")
-		}
-		if err != nil {
-			crawlChan<- MovDetail{}
-		}
-		respBody := string(body)
+    if ctx.Err() != nil {
+        return exit_Failure, "Interrupted. "
+    }
+    if err == imdb.ErrNoMovieList {
+        return exit_Layout, "Unable to parse the chart. "
+    }
+    return exit_Fetch, "Unable to fetch the chart. "
+}
 
-		// expanded summary
-		summaryData = []byte(respBody[strings.Index(respBody, `<p>`) + len (`<p>`) : strings.Index(respBody, `</p>`)])
-	    }()
+// validateUrl just checks if the URL given as command-line is that of a page on IMDb,
+// of any host with -allow-any-url.
+func validateUrl () string {
+    if err := imdb.ValidateURL (flag.Arg(0), *allowAnyURL); err != nil {
+        fail (exit_Usage, "Invalid URL. ", err)
     }
+    return flag.Arg(0)
+}
 
-    // genre
-    genreSecStrtIdx := strings.Index(respBody[durEndIdx : ], field_separator) + durEndIdx + len (field_separator)
-    genreSecEndIdx := strings.Index(respBody[genreSecStrtIdx : ], field_separator) + genreSecStrtIdx
+// serveDebugVars serves the default HTTP mux, hence /debug/vars, on the given
+// address in the background. See imdb/metrics.go
+func serveDebugVars (addr string) {
+
+    go func (){
+        if err := http.ListenAndServe (addr, nil); err != nil {
+            log.Println ("FAILURE: Could not serve the debug vars.", err)
+        }
+    }()
+}
 
-    // the movie can be of multiple genres, each having a <a> HTML element
-    // filetering out & splitting using regexp
-    r := regexp.MustCompile (`</a>`)
-    genreCatLnks := r.Split(respBody[genreSecStrtIdx : genreSecEndIdx], -1)
+// chartOutput provides the crawled chart in the format asked for, JSON by default,
+// wrapped with the chart title & URL as metadata if an envelope is requested.
+func chartOutput (chart *imdb.Chart, chart_url string, requested_count int) string {
 
-    genreLst := []string {}
+    imdbChartTable := chart.Movies
 
-    // create a slice of genres and later join them
-    // better than creating multiple strings by concatenation
-    for _, v := range genreCatLnks {
-        genreCatIdx := strings.LastIndex(v, `>`)
-        if genreCatIdx == -1 {
-            continue
-        }
-        genreCatIdx++
-        genreLst = append (genreLst, v[genreCatIdx : ])
+    // SQL dump of the movies instead of JSON
+    if *outputFormat == "sql" {
+        return sqlDump (imdbChartTable, *sqlTable)
     }
 
-    wg.Wait()
+    // one line per movie for pasting as links
+    if *outputFormat == "links" {
+        return titleLinks (imdbChartTable)
+    }
 
-    // send the details via the channel to signal other goroutines of its completion
-    crawlChan<- MovDetail{
-	    string(summaryData),
-            strings.TrimSpace(respBody[durStrtIdx : durEndIdx]),
-            strings.Join(genreLst, ", "),
+    // a row per movie for the spreadsheets
+    if *outputFormat == "csv" {
+        rows, err := csvRows (imdbChartTable)
+        if err != nil {
+            fail (exit_Failure, "Unable to write csv. ", err)
         }
+        return rows
+    }
 
-}
+    // formats built in with their tag
+    if serialize, tagged := taggedFormats[*outputFormat]; tagged {
+        out, err := serialize (imdbChartTable)
+        if err != nil {
+            fail (exit_Failure, "Unable to write ", *outputFormat, ". ", err)
+        }
+        return out
+    }
 
-// getTitleData is triggered as a goroutine and it fetches & parses the data from
-// the IMDb row of the table. The function triggers the crawler as a goroutine with
-// relevant parameters to obtain the summary, genre & duration while it processes
-// other data present in the field like Movie title & release date.
-func getTitleData (movieRec string, t *TitleData, wg *sync.WaitGroup) {
-
-    defer wg.Done()
-
-    // title data
-    // contains title, release year, and link to summary, duration & genre
-    tdtitleAttr := `<td class="`+td_titleClass+`">`
-    titleStrtIdx := strings.Index(movieRec, tdtitleAttr) + len (tdtitleAttr)
-    titleEndIdx := strings.Index(movieRec[titleStrtIdx : ], `</td>`) + titleStrtIdx
-
-    // link to more info
-    moreInfoAttr := `<a href="`
-    urlStrtIdx := titleStrtIdx + strings.Index(movieRec[titleStrtIdx : titleEndIdx], moreInfoAttr) + len (moreInfoAttr)
-    urlEndIdx := urlStrtIdx + strings.Index(movieRec[urlStrtIdx : titleEndIdx], `"`)
-    moreInfoURL := imdb_url_Main + movieRec[urlStrtIdx : urlEndIdx]
-
-    // start crawler to fetch summary, duration & genre concurrently
-    crawlChan := make (chan MovDetail)
-    defer close (crawlChan)
-    go crawlForMoreInfo (moreInfoURL, crawlChan)
-
-    // only title
-    title := movieRec[titleStrtIdx + strings.Index(movieRec[titleStrtIdx : titleEndIdx], `>`) + 1 :
-                      titleStrtIdx + strings.LastIndex(movieRec[titleStrtIdx : titleEndIdx], `</a>`)]
-    t.Title = title
-
-    // release date
-    releaseDateAttr := `<span class="`+releaseYear_class+`">`
-    releaseYear := movieRec[titleStrtIdx + strings.Index(movieRec[titleStrtIdx : titleEndIdx], releaseDateAttr) + len (releaseDateAttr) + 1 :
-                            titleStrtIdx + strings.LastIndex(movieRec[titleStrtIdx : titleEndIdx], `</span>`) - 1]
-    year, err := strconv.ParseUint(releaseYear, 10, 64)
+    // convert the data in the structure to JSON format
+    var chartData interface{}
+    switch {
+    case *genreReportOn:
+        chartData = genreReport (imdbChartTable)
+    case *groupBy == "decade":
+        groups := map[string]interface{} {}
+        for decade, movies := range decadeGroups (imdbChartTable) {
+            groups[decade] = movieRecords (movies)
+        }
+        chartData = groups
+    default:
+        chartData = movieRecords (imdbChartTable)
+    }
+    if *envelopeOut {
+        chartData = ChartEnvelope{
+            SchemaVersion:  schema_Version,
+            SourceURL:      chart_url,
+            Chart:          chart.Title,
+            GeneratedAt:    time.Now().Format(time.RFC3339),
+            RequestedCount: requested_count,
+            ReturnedCount:  len (imdbChartTable),
+            AvailableCount: chart.AvailableCount,
+            Config:         runConfig,
+            Movies:         chartData,
+        }
+    }
+    // indented for reading, compact by default for piping into jq & the like
+    var imdbChart []byte
+    var err error
+    if *prettyJSON {
+        imdbChart, err = json.MarshalIndent (chartData, "", "  ")
+    } else {
+        imdbChart, err = json.Marshal (chartData)
+    }
     if err != nil {
-        log.Println ("FAILURE: Could not obtain release year for", title)
+        fail (exit_Failure, "Unable to parse records. ", err)
     }
-    t.ReleaseYear = year
-
-    // wait for the crawler to fetch the data and populate the structure
-    t.MovDetail = <-crawlChan
+    if *asciiOut {
+        imdbChart = asciiJSON (imdbChart)
+    }
+    return string(imdbChart)
 }
 
-// getRating handles the extraction of rating from the specific row for that movie.
-// As this is triggered as a goroutine, it processes the rating and populates the
-// correct field supplied concurrently.
-func getRating (movieRec string, rate *float64, wg *sync.WaitGroup) {
+func main(){
+    flag.Parse()
+
+    // check if proper arguments are provided, not needed for a list of IDs
+    var chart_url string
+    var item_count int
+    var err error
+    // the chart is given either by its name or by its URL
+    if *idsFrom == "" {
+        args := flag.Args()
+        if *chartName != "" {
+            chart_url, err = imdb.ChartURL (*chartName)
+            if err != nil {
+                fail (exit_Usage, "Invalid -chart. ", err)
+            }
+            if len (args) > 1 {
+                fail (exit_Usage, "-chart is instead of the URL, please provide only the total count of movies")
+            }
+        } else {
+            if len (args) < 2 && !(*countOnly && len (args) == 1) {
+                fail (exit_Usage, "Please provide the URL and the total count of movies")
+            }
+            chart_url = validateUrl()
+            args = args[1 : ]
+        }
+        if len (args) == 0 && !*countOnly {
+            fail (exit_Usage, "Please provide the total count of movies")
+        }
 
-    defer wg.Done()
+        // the count is of no use for -count-only, hence optional then
+        if len (args) > 0 {
+            item_count, err = strconv.Atoi (args[0])
+            if err != nil {
+                fail (exit_Usage, "Invalid count of movies. ", err)
+            }
+            if item_count < 0 {
+                fail (exit_Usage, "The count of movies should not be negative")
+            }
+        }
+    }
+    if *chartName != "" && *idsFrom != "" {
+        fail (exit_Usage, "-chart is for a chart, not with -ids-from")
+    }
+    if *countOnly && (*idsFrom != "" || *inputFile != "") {
+        fail (exit_Usage, "-count-only is for a chart to fetch, not with -ids-from or -input")
+    }
+    if *unknownVotes != "keep" && *unknownVotes != "drop" {
+        fail (exit_Usage, "Invalid -unknown-votes. Should be either keep or drop")
+    }
+    if *groupBy != "" && *groupBy != "decade" {
+        fail (exit_Usage, "Invalid -group-by. Only decade is supported")
+    }
+    if _, tagged := taggedFormats[*outputFormat]; *outputFormat != "json" && *outputFormat != "sql" && *outputFormat != "links" && *outputFormat != "csv" && *outputFormat != "ndjson" && !tagged {
+        fail (exit_Usage, "Invalid -format. Should be json, sql, links, csv or ndjson, or one built in with its tag e.g. parquet")
+    }
+    fieldsDetails := false
+    if *fieldsOpt != "" {
+        var unknown string
+        outputFields, fieldsDetails, unknown = parseFields (*fieldsOpt)
+        if unknown != "" {
+            fail (exit_Usage, "Invalid -fields. No field ", strconv.Quote(unknown), " e.g. title, imdb_rating, summary")
+        }
+        if *outputFormat != "json" && *outputFormat != "ndjson" {
+            fail (exit_Usage, "-fields is for the JSON output, not -format=", *outputFormat)
+        }
+        if *liteOutput || *genreReportOn {
+            fail (exit_Usage, "-fields is for the movies, not with -lite or -genre-report")
+        }
+    }
+    if *dryRun && (*idsFrom != "" || *countOnly || *outputFormat != "json") {
+        fail (exit_Usage, "-dry-run is for the JSON output of a chart, not with -ids-from, -count-only or -format=", *outputFormat)
+    }
+    if *dryRun && (*liteOutput || *titleTypes != "") {
+        // the title type is on the detail pages, none of which a dry run fetches
+        fail (exit_Usage, "-dry-run outputs the detail pages rather than the movies, not with -lite, nor with -type which needs the detail pages")
+    }
+    if *asciiOut && *outputFormat != "json" && *outputFormat != "ndjson" {
+        fail (exit_Usage, "-ascii is for the JSON output, not -format=", *outputFormat)
+    }
+    if *outputFormat == "ndjson" && (*sortBy != "" || *minRating > 0 || *lazyDetails || *idsFrom != "" || *inputFile != "" || *strictRun) {
+        fail (exit_Usage, "-format=ndjson writes the movies as they are crawled, not with -sort, -min-rating, -lazy-details, -ids-from, -input or -strict")
+    }
+    if *outputFormat != "json" && (*genreReportOn || *groupBy != "" || *envelopeOut) {
+        fail (exit_Usage, "-format=", *outputFormat, " is for the movies only, not with -genre-report, -group-by or -envelope")
+    }
+    if *outputFormat == "sql" && !sqlIdentRegexp.MatchString(*sqlTable) {
+        fail (exit_Usage, "Invalid -sql-table. Should be letters, digits & underscores")
+    }
 
-    // rating
-    tdRatingAttr := `<td class="`+td_ratingClass+`">`
-    ratingStrtIdx := strings.Index(movieRec, tdRatingAttr) + len (tdRatingAttr)
-    ratingEndIdx := strings.Index(movieRec[ratingStrtIdx : ], `</td>`) + ratingStrtIdx
+    // the crawl as per the command-line options, the details being skipped for the
+    // lite output & the links unless needed for the report
+    opts := imdb.Options{
+        Details:              ((!*liteOutput && *outputFormat != "links") || *genreReportOn) && (*fieldsOpt == "" || fieldsDetails) && !*dryRun,
+        LazyDetails:          *lazyDetails,
+        Storyline:            *storylineOn,
+        MaxStorylineRequests: *maxSummaryReqs,
+        TVEpisodes:           *tvEpisodes,
+        TranslateTo:          *translateTo,
+        TranslateURL:         *translateURL,
+        PrettyDuration:       *prettyDurationOn,
+        CollapseGenres:       *collapseGenresOn || *genreMap != "",
+        NormalizeTitle:       *normalizeTitle,
+        IncludePosterURL:     *posterURL,
+        MaxSummaryLength:     *maxSummary,
+        ExtractSummary:       *summaryRegex,
+        ExtractDuration:      *durationRegex,
+        ExtractGenre:         *genreRegex,
+        RankFrom:             *rankFrom,
+        RankTo:               *rankTo,
+        MinRating:            *minRating,
+        MinVotes:             *minVotes,
+        KeepUnknownVotes:     *unknownVotes == "keep",
+        SortBy:               *sortBy,
+        SortStable:           *sortStable,
+        UserAgent:            *userAgentOpt,
+        Cookie:               *cookieHeader,
+        Proxy:                *proxyOpt,
+        InsecureTLS:          *insecureTLS,
+        Timeout:              *requestTimeout,
+        PoolIdleTimeout:      *poolIdleTimeout,
+        MaxRedirects:         *maxRedirects,
+        Concurrency:          *concurrency,
+        DetailConcurrency:    *detailConc,
+        RequestDelay:         *requestDelay,
+        AdaptiveMax:          *adaptiveMax,
+        AdaptiveLatency:      *adaptiveLatency,
+        CacheDir:             *cacheDir,
+        CacheTTL:             *cacheTTL,
+        RespectRobots:        !*ignoreRobots,
+        LogLevel:             *logLevel,
+    }
+    if *titleTypes != "" {
+        opts.TitleTypes = strings.Split(*titleTypes, ",")
+    }
 
-    rating := movieRec[ratingStrtIdx + strings.Index(movieRec[ratingStrtIdx : ratingEndIdx], `>`) + 1 :
-                       ratingStrtIdx + strings.LastIndex (movieRec[ratingStrtIdx : ratingEndIdx], `</strong>`)]
-    imdbRate,err := strconv.ParseFloat(rating, 64)
-    if err != nil {
-        log.Println ("FAILURE: Could not obtain rating")
+    // load the baseline upfront rather than failing after the crawl
+    if *newSince != "" {
+        opts.Baseline, err = imdb.LoadBaseline (*newSince)
+        if err != nil {
+            fail (exit_Failure, "Unable to load the baseline. ", err)
+        }
     }
-    *rate = imdbRate
-}
 
-// parseTableData is the master that is responsible for trigerring the proper
-// goroutine and synchronizing them, all while parsing the given data as per the
-// IMDb website.
-// The rows, for the specific movie, is split and processed. Then end result is
-// the requested number of records or the maximum number of records currently
-// available for that category.
-// When all the movies are processed, they are dumped as JSON string.
-func parseTableData(table string, item_count int, parserChan chan<- string) {
+    // genre buckets other than the built-in ones
+    if *genreMap != "" {
+        opts.GenreBuckets, err = imdb.LoadGenreBuckets (*genreMap)
+        if err != nil {
+            fail (exit_Failure, "Unable to load the genre map. ", err)
+        }
+    }
 
-    var wg sync.WaitGroup
+    // rotate the User-Agents, best-effort against being blocked on big crawls
+    if *userAgentsFile != "" {
+        opts.UserAgents, err = imdb.LoadUserAgents (*userAgentsFile)
+        if err != nil {
+            fail (exit_Failure, "Unable to load the User-Agents. ", err)
+        }
+    }
 
-    r := regexp.MustCompile (`<tr>*`)
+    // trust the CA of e.g. a TLS-inspecting proxy
+    if *caCert != "" {
+        opts.RootCAs, err = imdb.LoadCACerts (*caCert)
+        if err != nil {
+            fail (exit_Failure, "Unable to load the CA certificates. ", err)
+        }
+    }
 
-    recSlc := r.Split(table, -1)
-    recSlc = recSlc[2:]
+    // serve the fetches from the saved responses instead of the network
+    if *replayArchive != "" {
+        opts.Fetcher, err = imdb.LoadArchive (*replayArchive)
+        if err != nil {
+            fail (exit_Failure, "Unable to load replay archive. ", err)
+        }
+    }
 
-    if (item_count > len (recSlc)){
-        log.Printf ("ALARM: Only %d records available\n", len (recSlc))
-	item_count = len (recSlc)
+    // keep the diagnostics separate from the result data
+    if *warningsOut != "" {
+        sink, err := os.OpenFile (*warningsOut, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+        if err != nil {
+            fail (exit_Failure, "Unable to open warnings sink. ", err)
+        }
+        defer sink.Close()
+        opts.Warnings = sink
     }
 
-    imdbChartTable := make([]ImdbChartData, item_count)
+    // the options of the crawl (the rank window, -sort, -normalize-title, -translate-*,
+    // -concurrency, -log-level, -extract-*-regex etc.) validated by the package, before any fetch
+    if err := imdb.Configure (opts); err != nil {
+        fail (exit_Usage, err)
+    }
 
-    for i, mov := range recSlc {
-        if (i == item_count) {
-            break
+    // provenance of the output
+    if *recordConfig != "" {
+        runConfig = newRunConfig (chart_url, item_count)
+        if err := writeRunConfig (runConfig, *recordConfig); err != nil {
+            fail (exit_Failure, "Unable to record the configuration. ", err)
         }
-        wg.Add(2)
-        go getTitleData (mov, &imdbChartTable[i].TitleData, &wg)
-        go getRating (mov, &imdbChartTable[i].Rating, &wg)
     }
 
-    // wait for the goroutines to complete populating the fields
-    wg.Wait()
+    // the output goes to the file instead and/or to the tee file as well, opened
+    // upfront rather than failing after the crawl
+    var out io.Writer = os.Stdout
+    var outFile *os.File
+    if *outputFile != "" {
+        outFile, err = os.Create (*outputFile)
+        if err != nil {
+            fail (exit_Failure, "Unable to create the output file. ", err)
+        }
+        out = outFile
+    }
+    if *teeOut != "" {
+        teeFile, err := os.Create (*teeOut)
+        if err != nil {
+            fail (exit_Failure, "Unable to create the tee file. ", err)
+        }
+        defer teeFile.Close()
+        out = io.MultiWriter (out, teeFile)
+    }
+    var errorLogFile *os.File
+    if *errorLog != "" {
+        errorLogFile, err = os.Create (*errorLog)
+        if err != nil {
+            fail (exit_Failure, "Unable to create the error log. ", err)
+        }
+    }
 
-    // convert the data in the structure to JSON format
-    imdbChart, err := json.Marshal (imdbChartTable)
-    if err != nil {
-        log.Fatal ("ERROR: Unable to parse records", err)
+    // expose the runtime counters while the crawl is on
+    if *debugAddr != "" {
+        serveDebugVars (*debugAddr)
     }
 
-    // send the data back to the caller
-    parserChan<- string(imdbChart)
-}
+    // the crawl is abandoned on Ctrl-C (or SIGTERM), the requests in flight being
+    // cancelled rather than waited for
+    ctx, cancel := context.WithCancel (context.Background())
+    defer cancel()
+    interrupt := make (chan os.Signal, 1)
+    signal.Notify (interrupt, os.Interrupt, syscall.SIGTERM)
+    go func (){
+        <-interrupt
+        cancel()
+    }()
 
-// validateUrl just checks if the URL given as command-line is one of the URLs configured.
-func validateUrl () string {
-    switch os.Args[1]{
-    case chart_url_Indian, chart_url_Telugu, chart_url_Tamil: return os.Args[1]
-    default: log.Fatal ("Invalid URL")
+    crawlStart = time.Now()
+
+    // only the number of the movies listed, none of them crawled
+    if *countOnly {
+        available, err := imdb.CountMovies (ctx, chart_url)
+        if err != nil {
+            failCrawl (ctx, err)
+        }
+        count, _ := json.Marshal (ChartCount{available})
+        if _, err = fmt.Fprintln (out, string(count)); err == nil && outFile != nil {
+            err = outFile.Close()
+        }
+        if err != nil {
+            fail (exit_Failure, "Unable to write the output. ", err)
+        }
+        logStats()
+        return
     }
-    return ""
-}
 
-func main(){
-    // check if proper arguments are provided
-    if len (os.Args) < 3 {
-        log.Fatal ("Please provide the URL and the total count of movies")
+    var chart *imdb.Chart
+    streamed := make (chan error, 1)
+    if *idsFrom != "" {
+        // the details of each of the IDs in the list, instead of a chart
+        data, err := ioutil.ReadFile (*idsFrom)
+        if err != nil {
+            fail (exit_Failure, "Unable to load the IDs. ", err)
+        }
+        chart, err = imdb.CrawlIDs (ctx, strings.Split(string(data), "\n"))
+        if err != nil {
+            fail (exit_Failure, "Interrupted. ", err)
+        }
+        chart.Title = *idsFrom
+        item_count = chart.AvailableCount
+    } else {
+        if *inputFile != "" {
+            // the chart page saved earlier, the chart URL telling its layout
+            var page []byte
+            if *inputFile == "-" {
+                page, err = ioutil.ReadAll (os.Stdin)
+            } else {
+                page, err = ioutil.ReadFile (*inputFile)
+            }
+            if err != nil {
+                fail (exit_Failure, "Unable to read the chart page. ", err)
+            }
+            chart, err = imdb.CrawlPage (ctx, chart_url, string(page), item_count)
+        } else if *outputFormat == "ndjson" {
+            // the movies are written as they are crawled, not once all of them are
+            movies := make (chan imdb.ImdbChartData)
+            go streamMovies (movies, out, streamed)
+            chart, err = imdb.CrawlStream (ctx, chart_url, item_count, movies)
+        } else {
+            chart, err = imdb.Crawl (ctx, chart_url, item_count)
+        }
+        if err != nil {
+            failCrawl (ctx, err)
+        }
     }
 
-    chart_url := validateUrl()
-    item_count, err := strconv.Atoi (os.Args[2])
-    if err != nil {
-        log.Fatal ("ERROR:", err)
+    // the detail pages that would be crawled, none of them being fetched
+    if *dryRun {
+        plan := DryRunPlan{chart_url, []string {}}
+        for _, mov := range chart.Movies {
+            if mov.DetailURL != "" {
+                plan.Details = append (plan.Details, mov.DetailURL)
+            }
+        }
+        data, _ := json.Marshal (plan)
+        if *asciiOut {
+            data = asciiJSON (data)
+        }
+        if _, err = fmt.Fprintln (out, string(data)); err == nil && outFile != nil {
+            err = outFile.Close()
+        }
+        if err != nil {
+            fail (exit_Failure, "Unable to write the output. ", err)
+        }
+        logStats()
+        return
+    }
+
+    // the movies that failed apart, for a re-run, even if the run fails for them
+    if errorLogFile != nil {
+        if err = writeErrorLog (chart.Movies, errorLogFile); err == nil {
+            err = errorLogFile.Close()
+        }
+        if err != nil {
+            fail (exit_Failure, "Unable to write the error log. ", err)
+        }
     }
 
-    // Obtain the IMDb result body via http GET request
-    resp, err := http.Get (chart_url)
-    if err != nil{
-        log.Fatal ("ERROR: Failed to establish GET request")
+    // nothing but complete movies for -strict
+    if *strictRun && chart.Failures > 0 {
+        fail (exit_Partial, "Incomplete movies. Failed ", chart.Failures, " time(s) to fetch or parse their fields, see the warnings")
     }
-    if resp.StatusCode != http.StatusOK {
-        log.Fatal ("ERROR: Cannot process response. Response Code:", resp.StatusCode)
+
+    // the output of the tagged formats is binary, to be written as is
+    if _, tagged := taggedFormats[*outputFormat]; tagged {
+        _, err = fmt.Fprint (out, chartOutput (chart, chart_url, item_count))
+    } else if *outputFormat == "ndjson" {
+        err = <-streamed
+    } else {
+        _, err = fmt.Fprintln (out, chartOutput (chart, chart_url, item_count))
     }
-    defer resp.Body.Close()
-    body, err := ioutil.ReadAll(resp.Body)
-    if err != nil{
-        log.Fatal ("ERROR: Failed to obtain response body")
+    if err != nil {
+        fail (exit_Failure, "Unable to write the output. ", err)
     }
 
-    // only extract the table containing the movie list
-    tableStrtIdx := strings.Index(string(body), "<table")
-    tableEndIdx := strings.Index(string(body), "</table>")
-    table := string(body)[tableStrtIdx : tableEndIdx + len ("</table>")]
+    // the file is complete only once closed, e.g. on a full disk
+    if outFile != nil {
+        if err = outFile.Close(); err != nil {
+            fail (exit_Failure, "Unable to write the output. ", err)
+        }
+    }
 
-    // Start the master goroutine to parse the table and provide JSON dump
-    parserChan := make (chan string)
-    defer close (parserChan)
-    go parseTableData (table, item_count, parserChan)
+    logStats()
 
-    fmt.Println (<-parserChan)
+    // the output is the best effort, yet the run is not a success
+    if chart.Failures > 0 {
+        log.Println ("ERROR: Incomplete movies. Failed", chart.Failures, "time(s) to fetch or parse their fields, see the warnings")
+        os.Exit (exit_Partial)
+    }
 }
@@ -0,0 +1,63 @@
+/*
+ *-----------------------------------------------------------------
+ * IMDb Chart Fetcher - Tests of the exit codes
+ *-----------------------------------------------------------------
+ */
+package main
+
+import (
+    "errors"
+    "context"
+    "testing"
+
+    "github.com/sadhroh/Imdb-crawler/imdb"
+)
+
+// failingFetcher is the Fetcher failing for every URL but those of the given pages.
+type failingFetcher map[string]string
+
+var errFetch = errors.New ("dial tcp: connection refused")
+
+func (f failingFetcher) Get (ctx context.Context, url string) (string, error) {
+    if page, ok := f[url]; ok {
+        return page, nil
+    }
+    return "", errFetch
+}
+
+func TestCrawlFailure (t *testing.T) {
+
+    chartUrl, _ := imdb.ChartURL ("tamil")
+    cancelled, cancel := context.WithCancel (context.Background())
+    cancel()
+
+    tests := []struct {
+        name  string
+        ctx   context.Context
+        pages failingFetcher
+        code  int
+    }{
+        {"chart not fetched", context.Background(), nil, exit_Fetch},
+        {"no movie list", context.Background(), failingFetcher{chartUrl: "<html><body>Service Unavailable</body></html>"}, exit_Layout},
+        {"interrupted", cancelled, nil, exit_Failure},
+    }
+
+    for _, tt := range tests {
+        t.Run (tt.name, func (t *testing.T) {
+            o := imdb.DefaultOptions()
+            o.Fetcher = tt.pages
+            if err := imdb.Configure (o); err != nil {
+                t.Fatal (err)
+            }
+            defer imdb.Configure (imdb.DefaultOptions())
+
+            chart, err := imdb.Crawl (tt.ctx, chartUrl, 0)
+            if err == nil {
+                t.Fatalf ("crawled %d movies, want an error", len (chart.Movies))
+            }
+            if code, _ := crawlFailure (tt.ctx, err); code != tt.code {
+                t.Errorf ("exit code %d for %v, want %d", code, err, tt.code)
+            }
+        })
+    }
+}
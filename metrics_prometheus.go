@@ -0,0 +1,99 @@
+//go:build prometheus
+// +build prometheus
+
+/*
+ *-----------------------------------------------------------------
+ * IMDb Chart Fetcher - Prometheus Metrics
+ *-----------------------------------------------------------------
+ * Description: Prometheus counters & histograms of the crawl, served
+ *              at /metrics on the address given by -debug-addr:
+ *               - imdb_crawl_duration_seconds
+ *               - imdb_fetch_duration_seconds
+ *               - imdb_fetch_errors_total{type="request|status|body|interstitial"}
+ *               - imdb_movies_fetched_total
+ *               - imdb_cache_hits_total
+ *               - imdb_cache_misses_total
+ *               - imdb_cache_hit_ratio
+ *              This needs github.com/prometheus/client_golang, hence
+ *              it is only built with the prometheus tag:
+ *               go build -tags prometheus -o imdb_chart_fetcher .
+ *              The default build stays free of external packages.
+ *-----------------------------------------------------------------
+ */
+package main
+
+import (
+    "time"
+    "net/http"
+
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/prometheus/client_golang/prometheus/promhttp"
+    "github.com/sadhroh/Imdb-crawler/imdb"
+)
+
+// promObserver is the crawl observer which records the measurements as the
+// Prometheus metrics.
+type promObserver struct {
+    crawlDuration prometheus.Histogram
+    fetchDuration prometheus.Histogram
+    fetchErrors   *prometheus.CounterVec
+    moviesFetched prometheus.Counter
+}
+
+func init () {
+
+    o := promObserver{
+        crawlDuration: prometheus.NewHistogram (prometheus.HistogramOpts{
+            Name:    "imdb_crawl_duration_seconds",
+            Help:    "Time taken to crawl a chart including the detail pages.",
+            Buckets: prometheus.ExponentialBuckets (0.5, 2, 10),
+        }),
+        fetchDuration: prometheus.NewHistogram (prometheus.HistogramOpts{
+            Name:    "imdb_fetch_duration_seconds",
+            Help:    "Latency of the requests to IMDb.",
+            Buckets: prometheus.DefBuckets,
+        }),
+        fetchErrors: prometheus.NewCounterVec (prometheus.CounterOpts{
+            Name: "imdb_fetch_errors_total",
+            Help: "Failed requests to IMDb by the type of the failure.",
+        }, []string{"type"}),
+        moviesFetched: prometheus.NewCounter (prometheus.CounterOpts{
+            Name: "imdb_movies_fetched_total",
+            Help: "Movies populated from the crawled charts.",
+        }),
+    }
+    prometheus.MustRegister (o.crawlDuration, o.fetchDuration, o.fetchErrors, o.moviesFetched)
+
+    // the cache as counted by the crawler itself
+    prometheus.MustRegister (
+        prometheus.NewCounterFunc (prometheus.CounterOpts{
+            Name: "imdb_cache_hits_total",
+            Help: "Detail pages served by the cache.",
+        }, func () float64 { return float64(imdb.ReadStats().CacheHits) }),
+        prometheus.NewCounterFunc (prometheus.CounterOpts{
+            Name: "imdb_cache_misses_total",
+            Help: "Detail pages not in the cache, hence fetched.",
+        }, func () float64 { return float64(imdb.ReadStats().CacheMisses) }),
+        prometheus.NewGaugeFunc (prometheus.GaugeOpts{
+            Name: "imdb_cache_hit_ratio",
+            Help: "Share of the lookups of the cache served by it.",
+        }, func () float64 { return imdb.ReadStats().CacheHitRatio() }),
+    )
+
+    imdb.SetObserver (o)
+    http.Handle ("/metrics", promhttp.Handler())
+}
+
+func (o promObserver) Fetched (d time.Duration, errType string) {
+
+    o.fetchDuration.Observe (d.Seconds())
+    if errType != "" {
+        o.fetchErrors.WithLabelValues (errType).Inc()
+    }
+}
+
+func (o promObserver) Crawled (d time.Duration, movies int) {
+
+    o.crawlDuration.Observe (d.Seconds())
+    o.moviesFetched.Add (float64(movies))
+}
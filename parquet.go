@@ -0,0 +1,81 @@
+//go:build parquet
+// +build parquet
+
+/*
+ *-----------------------------------------------------------------
+ * IMDb Chart Fetcher - Parquet Output
+ *-----------------------------------------------------------------
+ * Description: Serializes the fetched movies as an Apache Parquet
+ *              file for the analytics tools (Spark, DuckDB, pandas),
+ *              written to the standard output with -format=parquet:
+ *               imdb_chart_fetcher -format=parquet <url> 100 > movies.parquet
+ *              One row per movie with the fields of ImdbChartData as
+ *              the columns, the genres being a list column rather
+ *              than the comma separated text.
+ *              This needs github.com/parquet-go/parquet-go, hence it
+ *              is only built with the parquet tag:
+ *               go build -tags parquet -o imdb_chart_fetcher .
+ *              The default build stays free of external packages.
+ *-----------------------------------------------------------------
+ */
+package main
+
+import (
+    "bytes"
+    "strings"
+
+    "github.com/parquet-go/parquet-go"
+    "github.com/sadhroh/Imdb-crawler/imdb"
+)
+
+// Structure to maintain a movie as a row of the Parquet file
+// facilitates easy conversion from structure to the schema by using the meta-fields
+type parquetMovie struct {
+    IMDbID      string   `parquet:"imdb_id"`
+    Title       string   `parquet:"title"`
+    ReleaseYear uint64   `parquet:"release_year"`
+    Rating      float64  `parquet:"imdb_rating"`
+    Votes       uint64   `parquet:"votes"`
+    URL         string   `parquet:"url"`
+    Summary     string   `parquet:"summary"`
+    Storyline   string   `parquet:"storyline,optional"`
+    Duration    string   `parquet:"duration"`
+    Genres      []string `parquet:"genres,list"`
+    TitleType   string   `parquet:"title_type"`
+}
+
+func init () {
+    taggedFormats["parquet"] = parquetFile
+}
+
+// parquetFile provides the Parquet file having the movies as its rows.
+func parquetFile (imdbChartTable []imdb.ImdbChartData) (string, error) {
+
+    rows := make([]parquetMovie, len (imdbChartTable))
+
+    for i, mov := range imdbChartTable {
+        var genres []string
+        if mov.Genre != "" {
+            genres = strings.Split(mov.Genre, ", ")
+        }
+        rows[i] = parquetMovie{
+            IMDbID:      mov.IMDbID,
+            Title:       mov.Title,
+            ReleaseYear: mov.ReleaseYear,
+            Rating:      mov.Rating,
+            Votes:       mov.Votes,
+            URL:         mov.DetailURL,
+            Summary:     mov.Summary,
+            Storyline:   mov.Storyline,
+            Duration:    mov.Duration,
+            Genres:      genres,
+            TitleType:   mov.TitleType,
+        }
+    }
+
+    var buf bytes.Buffer
+    if err := parquet.Write (&buf, rows); err != nil {
+        return "", err
+    }
+    return buf.String(), nil
+}
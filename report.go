@@ -0,0 +1,119 @@
+/*
+ *-----------------------------------------------------------------
+ * IMDb Chart Fetcher - Reports
+ *-----------------------------------------------------------------
+ * Description: Analytical projections over the fetched list of
+ *              movies, provided instead of the raw list when asked
+ *              for on the command-line.
+ *               - genre report: for each genre, the number of
+ *                 movies along with their mean & median rating,
+ *                 sorted by the mean rating, highest first.
+ *                 A movie contributes to each of its genres.
+ *               - decade groups: the movies grouped by the decade
+ *                 of their release e.g. "1980s", in the chart order
+ *                 within a decade. Movies whose release year is
+ *                 unknown are grouped as "unknown".
+ *-----------------------------------------------------------------
+ */
+package main
+
+import (
+    "sort"
+    "math"
+    "strconv"
+    "strings"
+
+    "github.com/sadhroh/Imdb-crawler/imdb"
+)
+
+// group of the movies whose release year is unknown
+const (
+    decade_Unknown = `unknown`
+)
+
+// Structure to maintain the statistics of the ratings of a particular genre
+// facilitates easy conversion from structure to json by using the meta-fields
+type GenreStat struct {
+    Genre        string  `json:"genre"`
+    Count        int     `json:"count"`
+    MeanRating   float64 `json:"mean_rating"`
+    MedianRating float64 `json:"median_rating"`
+}
+
+// genreReport groups the ratings of the movies by genre & provides the statistics
+// of each genre sorted by the mean rating in descending order. Movies without any
+// genre are not considered.
+func genreReport (imdbChartTable []imdb.ImdbChartData) []GenreStat {
+
+    genreRatings := map[string][]float64 {}
+    for _, mov := range imdbChartTable {
+        if mov.Genre == "" {
+            continue
+        }
+        for _, genre := range strings.Split(mov.Genre, ", ") {
+            genreRatings[genre] = append (genreRatings[genre], mov.Rating)
+        }
+    }
+
+    report := []GenreStat {}
+    for genre, ratings := range genreRatings {
+        report = append (report, GenreStat{
+            Genre:        genre,
+            Count:        len (ratings),
+            MeanRating:   roundRating (mean (ratings)),
+            MedianRating: roundRating (median (ratings)),
+        })
+    }
+
+    // highest mean first, the genre name keeps the order deterministic on ties
+    sort.Slice (report, func (i, j int) bool {
+        if report[i].MeanRating != report[j].MeanRating {
+            return report[i].MeanRating > report[j].MeanRating
+        }
+        return report[i].Genre < report[j].Genre
+    })
+    return report
+}
+
+// decadeGroups groups the movies by the decade of their release, keeping the order
+// of the movies within each decade.
+func decadeGroups (imdbChartTable []imdb.ImdbChartData) map[string][]imdb.ImdbChartData {
+
+    groups := map[string][]imdb.ImdbChartData {}
+    for _, mov := range imdbChartTable {
+        decade := decade_Unknown
+        if mov.ReleaseYear != 0 {
+            decade = strconv.FormatUint(mov.ReleaseYear / 10 * 10, 10) + "s"
+        }
+        groups[decade] = append (groups[decade], mov)
+    }
+    return groups
+}
+
+// mean provides the arithmetic mean of the given ratings.
+func mean (ratings []float64) float64 {
+
+    sum := 0.0
+    for _, r := range ratings {
+        sum += r
+    }
+    return sum / float64(len (ratings))
+}
+
+// median provides the median of the given ratings. The given slice is left as is.
+func median (ratings []float64) float64 {
+
+    sorted := append ([]float64 {}, ratings...)
+    sort.Float64s (sorted)
+
+    mid := len (sorted) / 2
+    if len (sorted) % 2 == 0 {
+        return (sorted[mid - 1] + sorted[mid]) / 2
+    }
+    return sorted[mid]
+}
+
+// roundRating rounds off the computed rating to 2 decimal places.
+func roundRating (r float64) float64 {
+    return math.Round (r * 100) / 100
+}
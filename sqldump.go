@@ -0,0 +1,79 @@
+/*
+ *-----------------------------------------------------------------
+ * IMDb Chart Fetcher - SQL Dump
+ *-----------------------------------------------------------------
+ * Description: Serializes the fetched movies as an SQL dump, i.e. a
+ *              CREATE TABLE statement followed by one INSERT per
+ *              movie, to be loaded into any SQL database (Postgres,
+ *              MySQL, SQLite) without a driver, e.g.
+ *                imdb_chart_fetcher -format=sql <url> 10 | psql
+ *              The values are written as SQL literals, the quotes in
+ *              the text being doubled as per the SQL standard. MySQL
+ *              needs NO_BACKSLASH_ESCAPES should a text have a
+ *              backslash.
+ *-----------------------------------------------------------------
+ */
+package main
+
+import (
+    "regexp"
+    "strconv"
+    "strings"
+
+    "github.com/sadhroh/Imdb-crawler/imdb"
+)
+
+// plain SQL identifier, so that the table name needs no quoting
+var sqlIdentRegexp = regexp.MustCompile (`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// columns of the table in the order of the values in the INSERT statements
+const (
+    sql_Columns = `imdb_id, title, release_year, imdb_rating, votes, url, summary, storyline, duration, genre, title_type`
+)
+
+// sqlDump provides the CREATE TABLE & the INSERT statements for the movies in the
+// given table.
+func sqlDump (imdbChartTable []imdb.ImdbChartData, table string) string {
+
+    var sb strings.Builder
+
+    sb.WriteString("CREATE TABLE IF NOT EXISTS " + table + " (\n" +
+                   "    imdb_id      VARCHAR(16),\n" +
+                   "    title        TEXT,\n" +
+                   "    release_year INTEGER,\n" +
+                   "    imdb_rating  REAL,\n" +
+                   "    votes        BIGINT,\n" +
+                   "    url          TEXT,\n" +
+                   "    summary      TEXT,\n" +
+                   "    storyline    TEXT,\n" +
+                   "    duration     VARCHAR(32),\n" +
+                   "    genre        TEXT,\n" +
+                   "    title_type   VARCHAR(32)\n" +
+                   ");\n")
+
+    for _, mov := range imdbChartTable {
+        values := []string {
+            sqlString (mov.IMDbID),
+            sqlString (mov.Title),
+            strconv.FormatUint(mov.ReleaseYear, 10),
+            strconv.FormatFloat(mov.Rating, 'f', -1, 64),
+            strconv.FormatUint(mov.Votes, 10),
+            sqlString (mov.DetailURL),
+            sqlString (mov.Summary),
+            sqlString (mov.Storyline),
+            sqlString (mov.Duration),
+            sqlString (mov.Genre),
+            sqlString (mov.TitleType),
+        }
+        sb.WriteString("INSERT INTO " + table + " (" + sql_Columns + ") VALUES (" + strings.Join(values, ", ") + ");\n")
+    }
+    return sb.String()
+}
+
+// sqlString provides the text as an SQL string literal, NULL if empty.
+func sqlString (text string) string {
+    if text == "" {
+        return "NULL"
+    }
+    return "'" + strings.ReplaceAll(text, "'", "''") + "'"
+}
@@ -0,0 +1,84 @@
+/*
+ *-----------------------------------------------------------------
+ * IMDb Chart Fetcher - Streaming Writer
+ *-----------------------------------------------------------------
+ * Description: Writer for the streaming outputs, where each record
+ *              (movie) is written as a line as soon as it is ready,
+ *              e.g. JSON lines. The records complete in their own
+ *              goroutines, so the writes to the same io.Writer are
+ *              serialized & each line goes out in a single write,
+ *              never interleaved with another.
+ *-----------------------------------------------------------------
+ */
+package main
+
+import (
+    "io"
+    "sync"
+    "encoding/json"
+
+    "github.com/sadhroh/Imdb-crawler/imdb"
+)
+
+// lineWriter is the io.Writer wrapper which writes whole lines, safe for concurrent
+// use by many goroutines.
+type lineWriter struct {
+    mu sync.Mutex
+    w  io.Writer
+}
+
+// newLineWriter wraps the writer for writing lines concurrently.
+func newLineWriter (w io.Writer) *lineWriter {
+    return &lineWriter{w: w}
+}
+
+// WriteLine writes the line followed by a newline, in one go.
+func (lw *lineWriter) WriteLine (line []byte) error {
+
+    buf := make([]byte, 0, len (line) + 1)
+    buf = append (append (buf, line...), '\n')
+
+    lw.mu.Lock()
+    defer lw.mu.Unlock()
+
+    _, err := lw.w.Write (buf)
+    return err
+}
+
+// WriteJSON writes the record as a line of JSON.
+func (lw *lineWriter) WriteJSON (record interface{}) error {
+
+    line, err := json.Marshal (record)
+    if err != nil {
+        return err
+    }
+    if *asciiOut {
+        line = asciiJSON (line)
+    }
+    return lw.WriteLine (line)
+}
+
+// streamMovies writes each of the movies received to w as a line of JSON, the lite
+// ones with -lite or only the fields of -fields, till the channel is closed. The movies keep being received after
+// a failed write so that the crawl is not held up, the first error being sent to
+// done once over.
+func streamMovies (movies <-chan imdb.ImdbChartData, w io.Writer, done chan<- error) {
+
+    lw := newLineWriter (w)
+
+    var err error
+    for mov := range movies {
+        if err != nil {
+            continue
+        }
+        switch {
+        case *liteOutput:
+            err = lw.WriteJSON (liteChart ([]imdb.ImdbChartData{mov})[0])
+        case len (outputFields) > 0:
+            err = lw.WriteJSON (pickedMovie{mov, outputFields})
+        default:
+            err = lw.WriteJSON (mov)
+        }
+    }
+    done<- err
+}
@@ -0,0 +1,77 @@
+/*
+ *-----------------------------------------------------------------
+ * IMDb Chart Fetcher - Tests of the streaming writer
+ *-----------------------------------------------------------------
+ */
+package main
+
+import (
+    "sync"
+    "bytes"
+    "runtime"
+    "strings"
+    "testing"
+    "encoding/json"
+
+    "github.com/sadhroh/Imdb-crawler/imdb"
+)
+
+// tricklingWriter writes a byte at a time, yielding in between, so that the writes
+// made concurrently interleave unless serialized by the caller.
+type tricklingWriter struct {
+    mu  sync.Mutex
+    buf bytes.Buffer
+}
+
+func (w *tricklingWriter) Write (p []byte) (int, error) {
+    for _, b := range p {
+        w.mu.Lock()
+        w.buf.WriteByte (b)
+        w.mu.Unlock()
+        runtime.Gosched()
+    }
+    return len (p), nil
+}
+
+func TestStreamMoviesConcurrent (t *testing.T) {
+
+    const count = 50
+    movies := make (chan imdb.ImdbChartData)
+    done := make (chan error, 1)
+    w := &tricklingWriter{}
+    go streamMovies (movies, w, done)
+
+    // the movies complete in goroutines of their own, as in a crawl
+    var wg sync.WaitGroup
+    for rank := 1; rank <= count; rank++ {
+        wg.Add(1)
+        go func (rank int) {
+            defer wg.Done()
+            mov := imdb.ImdbChartData{Rank: rank}
+            mov.Title = strings.Repeat ("Nayakan ", rank % 7 + 1)
+            mov.Summary = "A common man's struggle against a corrupt police force."
+            movies<- mov
+        }(rank)
+    }
+    wg.Wait()
+    close (movies)
+    if err := <-done; err != nil {
+        t.Fatal (err)
+    }
+
+    lines := strings.Split (strings.TrimSuffix (w.buf.String(), "\n"), "\n")
+    if len (lines) != count {
+        t.Fatalf ("%d lines, want %d", len (lines), count)
+    }
+    seen := map[int]bool {}
+    for i, line := range lines {
+        var mov imdb.ImdbChartData
+        if err := json.Unmarshal ([]byte(line), &mov); err != nil {
+            t.Fatalf ("line %d is not a movie: %v\n%s", i, err, line)
+        }
+        seen[mov.Rank] = true
+    }
+    if len (seen) != count {
+        t.Errorf ("%d movies, want %d", len (seen), count)
+    }
+}